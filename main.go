@@ -14,16 +14,39 @@ package main
 	*Use it wisely*
 */
 
-import artisan "artisanapi/src"
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	artisan "artisanapi/src"
+)
 
 func main() {
 
+	// An optional catalog data file can be passed with -catalog to add new pads/colorways without recompiling
+	catalogPath := flag.String("catalog", "", "path of a json catalog file extending the built-in catalog")
+	flag.Parse()
+
+	var catalog *artisan.Catalog
+	if *catalogPath != "" {
+		var err error
+		catalog, err = artisan.LoadCatalogFile(*catalogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
 	// Create's a new website session
 	// You can have multiple sessions but i don't suggest doing it. The website is already slow and has tons of spaghetti code
 	// let's stick to slow refreshing rates of requests and limitate us to 1 session.
 	// Proxies are completely overkill here, i think they don't even have rate limits. 
 	// PLEASE! Be kind and not abuse requests.
-	session := artisan.NewAPISession()
+	// Options can be used to set a custom http client/transport (timeouts, proxies, etc..) or a different base url.
+	session := artisan.NewAPISessionWithOptions(artisan.APISessionOptions{
+		Catalog: catalog,
+	})
 
 	// Fetch all the product details
 	hienMid, _ := session.ProductDetails(artisan.ProductDetailsBody{
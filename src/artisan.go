@@ -3,9 +3,11 @@ package artisan
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
@@ -14,8 +16,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -41,8 +45,6 @@ const (
 
 // Represent a single session of the Artisan website APIs
 type APISession struct {
-	// If true display a log for everything that happens in the API calls (like a Debug log)
-	EnableLogs bool
 	// The cookie array that contains all the cookies of the website in this exact session instance
 	Cookies []*http.Cookie
 	// The static cookies that are present in almost every request
@@ -51,16 +53,76 @@ type APISession struct {
 	shippingAddress *ShippingAddress
 	// Wheter if the address to checkout has been set or not
 	isAddressSet bool
+	// The products added to the cart in this session
+	cart []CartItem
+	// The http client used to send every request of this session
+	httpClient *http.Client
+	// The base url used in place of APIDomain for every request of this session
+	baseURL string
+	// The rate limiter every request of this session waits on (nil if requests are not limited)
+	rateLimiter *RateLimiter
+	// The policy used to retry the failed requests of this session (nil if requests are never retried)
+	retryPolicy *RetryPolicy
+	// The logger used for every record of this session (never nil)
+	logger *slog.Logger
+	// The catalog used for the scans and the product names of this session (never nil)
+	catalog *Catalog
+	// The index where the barcodes of the in stock products fetched are recorded (nil if they are not recorded)
+	barcodeIndex *BarcodeIndex
+	// The notifiers of the checkouts created by this session
+	notifiers []Notifier
+	// The max duration of the notifications of a checkout
+	notifyTimeout time.Duration
+	// The notifications still running in background
+	notifications sync.WaitGroup
 }
 
-// Create's a new APISession and init the session
+// Contains options for the creation of a new APISession
+type APISessionOptions struct {
+	// An optional custom http client used for every request (if nil a default client is used), set it to add timeouts, proxies, etc..
+	HTTPClient *http.Client
+	// An optional custom transport for the session client, if HTTPClient is also set the client is copied and the transport replaced
+	Transport http.RoundTripper
+	// An optional base url used in place of APIDomain (for example the url of an httptest.Server), defaults to APIDomain
+	BaseURL string
+	// An optional rate limiter every request waits on, the same limiter can be shared between multiple sessions
+	RateLimiter *RateLimiter
+	// An optional policy used to retry the requests failed for transient reasons (if nil requests are never retried),
+	// consider using DefaultRetryPolicy
+	RetryPolicy *RetryPolicy
+	// An optional logger for the structured records of the session: requests start/finish at Debug level, retries at Warn level,
+	// failed requests at Error level and cart/address changes at Info level (if nil nothing is logged)
+	Logger *slog.Logger
+	// An optional catalog used in place of the DefaultCatalog (see LoadCatalogFile to extend the built-in one with a data file)
+	Catalog *Catalog
+	// An optional discovery report (see DiscoverCatalog) applied to the catalog with Catalog.WithDiscovery so the scans request only
+	// the color/size combinations listed in the product pages (if it can't be applied it's ignored and a warning is logged)
+	Discovery *DiscoveryReport
+	// An optional index where the barcode of every in stock product fetched is recorded (see LoadBarcodeIndex to keep it between runs)
+	BarcodeIndex *BarcodeIndex
+	// Optional notifiers called with a CheckoutCreatedEvent every time a checkout is created. They run in background
+	// so they never delay nor fail the checkout (their failures are logged), see WaitNotifications
+	Notifiers []Notifier
+	// The max duration of the notifications of a checkout, it's not affected by the checkout context cancellation
+	// (if zero DefaultNotifyTimeout is used)
+	NotifyTimeout time.Duration
+}
+
+// The max duration of the notifications of a checkout if APISessionOptions.NotifyTimeout is not set
+const DefaultNotifyTimeout time.Duration = 2 * time.Minute
+
+// Create's a new APISession and init the session with the default options
 func NewAPISession() *APISession {
+	return NewAPISessionWithOptions(APISessionOptions{})
+}
+
+// Create's a new APISession and init the session with the given options
+func NewAPISessionWithOptions(options APISessionOptions) *APISession {
 	session := &APISession{
-		EnableLogs:   false,
 		isAddressSet: false,
 	}
 
-	session.initAPISession()
+	session.initAPISession(options)
 
 	return session
 }
@@ -107,45 +169,19 @@ const (
 	ShidenkaiV2MidMPad   MPad = "192"
 )
 
-// The total number of (mousepads types * hardness)
+// The total number of (mousepads types * hardness) in the DefaultCatalog, it must be kept equal to the number of built-in models
+//
+// Deprecated: use len(DefaultCatalog.MPads()) (or the session catalog) instead
 const MPadsTypesTotal int32 = 25
 
-// All the mousepads ulrs
-var MPadUrls = map[MPad]string{
-	ZeroClassicXSoftMPad: APIDomain + "/cs-zero-eng.html",
-	ZeroClassicSoftMPad:  APIDomain + "/cs-zero-eng.html",
-	ZeroClassicMidMPad:   APIDomain + "/cs-zero-eng.html",
-
-	RaidenClassicXSoftMPad: APIDomain + "/cs-raiden-eng.html",
-	RaidenClassicMidMPad:   APIDomain + "/cs-raiden-eng.html",
-
-	HayateOtsuXSoftMPad: APIDomain + "/fx-hayate-otsu-eng.html",
-	HayateOtsuSoftMPad:  APIDomain + "/fx-hayate-otsu-eng.html",
-	HayateOtsuMidMPad:   APIDomain + "/fx-hayate-otsu-eng.html",
-
-	HayateKouXSoftMPad: APIDomain + "/fx-hayate-kou-eng.html",
-	HayateKouSoftMPad:  APIDomain + "/fx-hayate-kou-eng.html",
-	HayateKouMidMPad:   APIDomain + "/fx-hayate-kou-eng.html",
+// All the mousepads ulrs (derived from the DefaultCatalog)
+var MPadUrls = DefaultCatalog.urls()
 
-	HienXSoftMPad: APIDomain + "/fx-hien-eng.html",
-	HienSoftMPad:  APIDomain + "/fx-hien-eng.html",
-	HienMidMPad:   APIDomain + "/fx-hien-eng.html",
+// Contains the series of every mousepad (derived from the DefaultCatalog)
+var MPadSeries = DefaultCatalog.series()
 
-	ZeroXSoftMPad: APIDomain + "/fx-zero-eng.html",
-	ZeroSoftMPad:  APIDomain + "/fx-zero-eng.html",
-	ZeroMidMPad:   APIDomain + "/fx-zero-eng.html",
-
-	RaidenXSoftMPad: APIDomain + "/fx-raiden-eng.html",
-	RaidenSoftMPad:  APIDomain + "/fx-raiden-eng.html",
-	RaidenMidMPad:   APIDomain + "/fx-raiden-eng.html",
-
-	Type99XSoftMPad: APIDomain + "/fx-99-eng.html",
-	Type99SoftMPad:  APIDomain + "/fx-99-eng.html",
-	Type99MidMPad:   APIDomain + "/fx-99-eng.html",
-
-	ShidenkaiV2XSoftMPad: APIDomain + "/fx-shidenkai-eng.html",
-	ShidenkaiV2MidMPad:   APIDomain + "/fx-shidenkai-eng.html",
-}
+// Contains the hardness of every mousepad (derived from the DefaultCatalog)
+var MPadHardness = DefaultCatalog.hardness()
 
 // Represent an artisan color
 type Color string
@@ -162,17 +198,8 @@ const (
 	GrayColor         Color = "13"
 )
 
-// Contains all the artisan color names (you can search a color inside by using a color constant as key)
-var ColorNames = map[Color]string{
-	WineRedColor:      "WineRed",
-	NinjaBlackColor:   "NinjaBlack",
-	BlackColor:        "Black",
-	SnowWhiteColor:    "SnowWhite",
-	CoffeeBrownColor:  "CoffeeBrown",
-	DaidaiOrangeColor: "Daidai Orange",
-	MatchaGreenColor:  "MatchaGreen",
-	GrayColor:         "Gray",
-}
+// Contains all the artisan color names (you can search a color inside by using a color constant as key), derived from the DefaultCatalog
+var ColorNames = DefaultCatalog.colorNames()
 
 // Represent an artisan size
 type Size string
@@ -186,14 +213,8 @@ const (
 	SizeXXL Size = "5"
 )
 
-// Contains all the artisan size names (you can search a size inside by using a size constant as key)
-var SizeNames = map[Size]string{
-	SizeS:   "S",
-	SizeM:   "M",
-	SizeL:   "L",
-	SizeXL:  "XL",
-	SizeXXL: "XXL",
-}
+// Contains all the artisan size names (you can search a size inside by using a size constant as key), derived from the DefaultCatalog
+var SizeNames = DefaultCatalog.sizeNames()
 
 // Contains informations used to communicate the product thru the apis
 type ProductDetailsBody struct {
@@ -216,30 +237,67 @@ type Product struct {
 	Size string
 	// The product color name
 	Color string
-	// The product price in yen(jpy)
+	// The product price in yen(jpy) as sent by the website (kept for compatibility, see PriceYen)
 	Price string
+	// The parsed product price (0 if the website didn't send a price)
+	PriceYen Yen
 	// The hardness of the product
 	Hardness string
 	// The product direct url
 	Url string
 	// Wheter if the product is out of stock or not
 	OutOfStock bool
+	// The parsed response the product has been built from (it contains the raw fields and the unknown extras)
+	Response *SyouhinResponse
 
 	*ProductDetailsBody
 }
 
 // Fetch details about a single given Product
 func (api *APISession) ProductDetails(pSearched ProductDetailsBody) (*Product, error) {
+	return api.ProductDetailsCtx(context.Background(), pSearched)
+}
 
-	// Create's a recover for possible panics
-	defer func() {
-		if err := recover(); err != nil {
-			if api.EnableLogs {
-				fmt.Println("[Panic] -> Error sending or parsing ProductDetails request, panic recovered")
-			}
-			return
+// Fetch details about a single given Product, the request is cancelled as soon as the context is done
+// and retried following the session RetryPolicy
+func (api *APISession) ProductDetailsCtx(ctx context.Context, pSearched ProductDetailsBody) (*Product, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	logger := api.logger.With(
+		slog.String("operation", "ProductDetails"),
+		slog.String("sir_id", string(pSearched.SirID)),
+		slog.String("color_id", string(pSearched.ColorID)),
+		slog.String("size_id", string(pSearched.SizeID)),
+	)
+
+	// Fetch the product retrying the transient failures
+	var resProduct *Product
+	err := api.retryPolicy.do(ctx, logRetry(ctx, logger), func(attempt int) error {
+		var err error
+		resProduct, err = api.fetchProductDetails(ctx, pSearched, logger.With(slog.Int("attempt", attempt)))
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "request failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	// Record the barcode of the in stock products
+	if api.barcodeIndex != nil && !resProduct.OutOfStock {
+		if err := api.barcodeIndex.Add(resProduct); err != nil {
+			logger.WarnContext(ctx, "failed to record the barcode", slog.Any("error", err))
 		}
-	}()
+	}
+
+	return resProduct, nil
+}
+
+// Send a single get_syouhin request and parse the product received
+func (api *APISession) fetchProductDetails(ctx context.Context, pSearched ProductDetailsBody, logger *slog.Logger) (*Product, error) {
 
 	// Encode the form data
 	formData := url.Values{
@@ -250,7 +308,7 @@ func (api *APISession) ProductDetails(pSearched ProductDetailsBody) (*Product, e
 	}
 
 	// Create's the post request
-	req, err := http.NewRequest("POST", APIGetSyouhin, bytes.NewBufferString(formData.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", api.resolveURL(APIGetSyouhin), bytes.NewBufferString(formData.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -259,13 +317,17 @@ func (api *APISession) ProductDetails(pSearched ProductDetailsBody) (*Product, e
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := api.send(ctx, api.httpClient, req, logger)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	// Check the response status
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
 	// Read the response body
 	resBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -273,127 +335,284 @@ func (api *APISession) ProductDetails(pSearched ProductDetailsBody) (*Product, e
 	}
 
 	// Elaborate the response
-	resBodySplitted := strings.Split(string(resBody), "/")
+	syouhin, err := ParseSyouhinResponse(resBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(syouhin.Extras) > 0 {
+		logger.WarnContext(ctx, "response schema drift, unknown extra fields", slog.Any("extras", syouhin.Extras), slog.String("payload", string(resBody)))
+	}
+
+	// The hardness of the catalog model is known even if it's not one of the built-in ones
+	if model, _ := api.catalog.Model(pSearched.SirID); !syouhin.HasKnownHardness() && Hardness(syouhin.Hardness) != model.Hardness {
+		logger.WarnContext(ctx, "response schema drift, unknown hardness", slog.String("hardness", syouhin.Hardness), slog.String("payload", string(resBody)))
+	}
+
+	// Parse the price (out of stock products may have no price)
+	var priceYen Yen
+	if syouhin.Price != "" {
+		if priceYen, err = ParseYen(syouhin.Price); err != nil {
+			return nil, &SchemaDriftError{Reason: err.Error(), Payload: string(resBody)}
+		}
+	}
 
 	resProduct := &Product{
-		Id:                 resBodySplitted[0],
-		OutOfStock:         resBodySplitted[0] == "NON",
-		Prefix:             resBodySplitted[1],
-		ShortName:          strings.Split(resBodySplitted[2], " ")[0],
-		FullName:           resBodySplitted[2],
-		Price:              resBodySplitted[3],
-		Hardness:           resBodySplitted[5],
-		Size:               SizeNames[pSearched.SizeID],
-		Color:              ColorNames[pSearched.ColorID],
-		Url:                MPadUrls[pSearched.SirID],
+		Id:                 syouhin.Id,
+		OutOfStock:         syouhin.Id == "NON",
+		Prefix:             syouhin.Prefix,
+		ShortName:          strings.Split(syouhin.Name, " ")[0],
+		FullName:           syouhin.Name,
+		Price:              syouhin.Price,
+		PriceYen:           priceYen,
+		Hardness:           syouhin.Hardness,
+		Size:               api.catalog.SizeName(pSearched.SizeID),
+		Color:              api.catalog.ColorName(pSearched.ColorID),
+		Url:                api.resolveURL(api.catalog.URL(pSearched.SirID)),
+		Response:           syouhin,
 		ProductDetailsBody: &pSearched,
 	}
 
 	return resProduct, nil
 }
 
+// Contains include/exclude filters for the products to fetch, an empty include list means everything is included
+// while the exclude lists always win over the include ones
+type ProductFilter struct {
+	IncludeMPads    []MPad
+	ExcludeMPads    []MPad
+	IncludeSeries   []Series
+	ExcludeSeries   []Series
+	IncludeHardness []Hardness
+	ExcludeHardness []Hardness
+	IncludeColors   []Color
+	ExcludeColors   []Color
+	IncludeSizes    []Size
+	ExcludeSizes    []Size
+}
+
+// Return's true if the product combination passes every filter (series and hardness are searched in the DefaultCatalog)
+func (filter *ProductFilter) Match(body ProductDetailsBody) bool {
+	return filter.matchIn(DefaultCatalog, body)
+}
+
+// Return's true if the product combination passes every filter searching series and hardness in the given catalog
+func (filter *ProductFilter) matchIn(catalog *Catalog, body ProductDetailsBody) bool {
+	model, _ := catalog.Model(body.SirID)
+
+	return filterMatch(body.SirID, filter.IncludeMPads, filter.ExcludeMPads) &&
+		filterMatch(model.Series, filter.IncludeSeries, filter.ExcludeSeries) &&
+		filterMatch(model.Hardness, filter.IncludeHardness, filter.ExcludeHardness) &&
+		filterMatch(body.ColorID, filter.IncludeColors, filter.ExcludeColors) &&
+		filterMatch(body.SizeID, filter.IncludeSizes, filter.ExcludeSizes)
+}
+
+// Return's true if the value is in the include list (or it's empty) and not in the exclude list
+func filterMatch[T comparable](value T, include []T, exclude []T) bool {
+	return (len(include) == 0 || slices.Contains(include, value)) && !slices.Contains(exclude, value)
+}
+
+// The default maximum number of concurrent requests used by AllProductsDetails if not specified in the options
+const DefaultMaxConcurrency int = 8
+
 // Contains options for the AllProductDetails fetch request
 type AllProductDetailsOptions struct {
 	// An optional callback that get's called every time a product is fetched asynchronously (note that modifying the product passed as parameter
 	// results in modifying also the product returned in the result slice)
 	ProductFetchedCallback func(*Product)
+	// The maximum number of requests sent at the same time (defaults to DefaultMaxConcurrency if <= 0)
+	MaxConcurrency int
+	// An optional delay every worker waits after each request before sending the next one
+	RequestDelay time.Duration
+	// An optional filter to fetch only some products, the combinations filtered out are never requested
+	Filter ProductFilter
+	// If true the product pages are discovered before the scan (see DiscoverCatalog) and only the color/size combinations
+	// listed in the pages are requested, the pages that couldn't be fetched keep the session catalog offers.
+	// The DefaultCatalog declares no offers, so without Discover (or APISessionOptions.Discovery) every color/size combination
+	// of every model is requested
+	Discover bool
+}
+
+// Represent a single product combination that AllProductsDetails failed to fetch
+type ProductFetchError struct {
+	// The combination that failed
+	ProductDetailsBody
+	// The cause of the failure
+	Err error
+}
+
+func (e *ProductFetchError) Error() string {
+	return fmt.Sprintf("Failed to fetch SirID: %s, ColorID: %s, SizeID: %s: %s", string(e.SirID), string(e.ColorID), string(e.SizeID), e.Err.Error())
+}
+
+func (e *ProductFetchError) Unwrap() error {
+	return e.Err
+}
+
+// Represent the result of an AllProductsDetails fetch, out of stock products are in Products (with OutOfStock set to true)
+// while the combinations that couldn't be fetched are in Failures
+type AllProductsDetailsResult struct {
+	// Every product fetched successfully
+	Products []*Product
+	// Every combination failed with it's cause
+	Failures []*ProductFetchError
+}
+
+// Return's all the failures joined in a single error (nil if there are no failures)
+func (result *AllProductsDetailsResult) Err() error {
+	errs := make([]error, len(result.Failures))
+	for i, failure := range result.Failures {
+		errs[i] = failure
+	}
+
+	return errors.Join(errs...)
 }
 
 // Fetch details about every Product (it does fetch using ProductDetails tasks asynchronously)
-func (api *APISession) AllProductsDetails(options AllProductDetailsOptions) ([]*Product, error) {
+func (api *APISession) AllProductsDetails(options AllProductDetailsOptions) (*AllProductsDetailsResult, error) {
+	return api.AllProductsDetailsCtx(context.Background(), options)
+}
 
-	// Create's a recover for possible panics
-	defer func() {
-		if err := recover(); err != nil {
-			if api.EnableLogs {
-				fmt.Println("[Panic] -> Error sending or parsing AllProductDetails request, panic recovered")
-			}
-			return
+// Fetch details about every Product (it does fetch using StreamProductsDetails), once the context is done no more requests are sent
+// and the in-flight requests are cancelled.
+// The result is always returned (unless the session is not initialized) with the products fetched and the failures, the error joins
+// every failure and the context error so it's nil only if every combination has been fetched
+func (api *APISession) AllProductsDetailsCtx(ctx context.Context, options AllProductDetailsOptions) (*AllProductsDetailsResult, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	// Create's the result to hold all the products fetched and the failures
+	result := &AllProductsDetailsResult{}
+
+	// Collect every result as soon as it arrives (the callback is called here so it never blocks the workers while holding a lock)
+	for pRes := range api.StreamProductsDetails(ctx, options) {
+		if pRes.Err != nil {
+			result.Failures = append(result.Failures, &ProductFetchError{ProductDetailsBody: pRes.Body, Err: pRes.Err})
+			continue
 		}
-	}()
 
-	// Create's a slice to hold all the products fetched
-	var resProducts []*Product
-
-	// Create's an array that contains every product to fetch
-	allProducts := [...]MPad{
-		ZeroClassicXSoftMPad,
-		ZeroClassicSoftMPad,
-		ZeroClassicMidMPad,
-		RaidenClassicXSoftMPad,
-		RaidenClassicMidMPad,
-		HayateOtsuXSoftMPad,
-		HayateOtsuSoftMPad,
-		HayateOtsuMidMPad,
-		HayateKouXSoftMPad,
-		HayateKouSoftMPad,
-		HayateKouMidMPad,
-		HienXSoftMPad,
-		HienSoftMPad,
-		HienMidMPad,
-		ZeroXSoftMPad,
-		ZeroSoftMPad,
-		ZeroMidMPad,
-		RaidenXSoftMPad,
-		RaidenSoftMPad,
-		RaidenMidMPad,
-		Type99XSoftMPad,
-		Type99SoftMPad,
-		Type99MidMPad,
-		ShidenkaiV2XSoftMPad,
-		ShidenkaiV2MidMPad,
-	}
-
-	// Prepare a sync wait group to wait for every request to finish before exiting the function
-	wg := sync.WaitGroup{}
-	wg.Add(len(allProducts) * len(ColorNames) * len(SizeNames))
-
-	// Create's a lock to access resProducts safely
-	resProductsLock := make(chan bool, 1)
-
-	// Iterates every product * every color * every size and create's a task for every req to complete it asynchronously
-	for _, product := range allProducts {
-		for color := range ColorNames {
-			for size := range SizeNames {
-				go func(product MPad, color Color, size Size) {
-					// Safely unlock the async function
-					defer wg.Done()
-
-					// Log if enable the request sending
-					if api.EnableLogs {
-						fmt.Printf("Sending ProductDetail request -> SirID: %s, ColorID: %s, SizeID: %s\n", product, color, size)
-					}
+		result.Products = append(result.Products, pRes.Product)
 
-					// Fetch details about the product
-					pRes, _ := api.ProductDetails(ProductDetailsBody{SirID: product, ColorID: color, SizeID: size})
+		// Calls the optional callback
+		if options.ProductFetchedCallback != nil {
+			options.ProductFetchedCallback(pRes.Product)
+		}
+	}
 
-					// Check if the result is valid
-					if pRes == nil {
-						return
-					}
+	return result, errors.Join(result.Err(), ctx.Err())
+}
+
+// Represent the result of a single product fetch sent by StreamProductsDetails
+type ProductResult struct {
+	// The product combination fetched
+	Body ProductDetailsBody
+	// The product fetched (nil if Err is not nil)
+	Product *Product
+	// The error of the fetch (nil if the product has been fetched)
+	Err error
+}
+
+// Fetch details about every Product offered in the session catalog (see Catalog.IsOffered) thru a bounded pool of workers sending each result in the returned channel as soon as it arrives.
+// The channel is unbuffered so the workers wait for the results to be received before fetching the next products (ProductFetchedCallback
+// is not used). The channel is closed once every product has been fetched or the context is done, in that case the pending results are dropped
+// so the caller must keep receiving until the channel is closed or cancel the context
+func (api *APISession) StreamProductsDetails(ctx context.Context, options AllProductDetailsOptions) <-chan ProductResult {
 
-					// Acquire the lock
-					resProductsLock <- true
+	results := make(chan ProductResult)
 
-					// Safely append the product fetched
-					resProducts = append(resProducts, pRes)
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		go func() {
+			defer close(results)
+
+			select {
+			case results <- ProductResult{Err: err}:
+			case <-ctx.Done():
+			}
+		}()
 
-					// Calls the optional callback
-					if options.ProductFetchedCallback != nil {
-						options.ProductFetchedCallback(pRes)
+		return results
+	}
+
+	// Create's the jobs channel and feed it with every product * every color * every size combination matching the filter
+	// until the context is done
+	jobs := make(chan ProductDetailsBody)
+
+	go func() {
+		defer close(jobs)
+
+		catalog := api.catalog
+		if options.Discover {
+			catalog = api.discoveredCatalog(ctx)
+		}
+
+		for _, product := range catalog.MPads() {
+			for _, color := range catalog.Colors() {
+				for _, size := range catalog.Sizes() {
+					body := ProductDetailsBody{SirID: product, ColorID: color, SizeID: size}
+					if !catalog.IsOffered(product, color, size) || !options.Filter.matchIn(catalog, body) {
+						continue
 					}
 
-					// Unlock the resource
-					<-resProductsLock
-				}(product, color, size)
+					select {
+					case jobs <- body:
+					case <-ctx.Done():
+						return
+					}
+				}
 			}
 		}
+	}()
+
+	// Get's the number of workers to spawn
+	workersCount := options.MaxConcurrency
+	if workersCount <= 0 {
+		workersCount = DefaultMaxConcurrency
+	}
+
+	// Prepare a sync wait group to close the results channel once every worker has finished
+	wg := sync.WaitGroup{}
+	wg.Add(workersCount)
+
+	// Spawn the workers, every worker fetch the jobs one by one until there are no more jobs or the context is done
+	for i := 0; i < workersCount; i++ {
+		go func() {
+			// Safely unlock the async function
+			defer wg.Done()
+
+			for body := range jobs {
+				// Fetch details about the product
+				pRes, err := api.ProductDetailsCtx(ctx, body)
+
+				// Send the result waiting for the receiver
+				select {
+				case results <- ProductResult{Body: body, Product: pRes, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+
+				// Waits the optional delay before the next request
+				if options.RequestDelay > 0 {
+					select {
+					case <-time.After(options.RequestDelay):
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
 	}
 
-	// Waits every request to finish
-	wg.Wait()
+	// Close the results once every worker has finished
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-	return resProducts, nil
+	return results
 }
 
 // Represent the official country name type
@@ -479,6 +698,86 @@ const (
 	VietNam              Country = "VietNam"
 )
 
+// Contains all the available countries (used to parse the country names)
+var Countries = []Country{
+	Argentina,
+	Australia,
+	Austria,
+	Azerbaijan,
+	Bahrain,
+	Bangladesh,
+	Belgium,
+	BosniaandHerzegovina,
+	Brazil,
+	BruneiDarussalam,
+	Bulgaria,
+	Canada,
+	Chile,
+	China,
+	Croatia,
+	Cyprus,
+	CzechRepublic,
+	Denmark,
+	Egypt,
+	Estonia,
+	Finland,
+	France,
+	Georgia,
+	Germany,
+	Greece,
+	Greenland,
+	Guam,
+	Hungary,
+	Iceland,
+	India,
+	Ireland,
+	Italy,
+	Kazakhstan,
+	Korea,
+	Kosovo,
+	Kuwait,
+	Latvia,
+	Liechtenstein,
+	Lithuania,
+	Luxembourg,
+	Macedonia,
+	Malaysia,
+	Malta,
+	Mexico,
+	Monaco,
+	Montenegro,
+	Morocco,
+	Netherlands,
+	NewCaledonia,
+	NewZealand,
+	Norway,
+	Oman,
+	Peru,
+	Poland,
+	Portugal,
+	PuertoRico,
+	Qatar,
+	Romania,
+	SanMarino,
+	SaudiArabia,
+	Serbia,
+	Singapore,
+	Slovakia,
+	Slovenia,
+	SouthAfrica,
+	Spain,
+	SriLanka,
+	Sweden,
+	Switzerland,
+	Taiwan,
+	Thailand,
+	Turkey,
+	UnitedArabEmirates,
+	UnitedKingdom,
+	UnitedStates,
+	VietNam,
+}
+
 // Represent the shipping address structure
 type ShippingAddress struct {
 	Name            string
@@ -494,29 +793,27 @@ type ShippingAddress struct {
 	Country         Country
 }
 
-// Set's the shipping address and return true if infos are correct (also auto URL encode strings)
-func (api *APISession) SetShippingAddress(address *ShippingAddress) bool {
+// Set's the shipping address and return nil if infos are correct or an ErrInvalidAddress error instead (also auto URL encode strings)
+func (api *APISession) SetShippingAddress(address *ShippingAddress) error {
+
+	if address == nil {
+		return fmt.Errorf("%w: address cannot be nil", ErrInvalidArgument)
+	}
 
 	// Check if there is an empty field
 	if !allFieldsNonEmpty(*address) {
-		return false
+		return fmt.Errorf("%w: every field must be filled", ErrInvalidAddress)
 	}
 
-	// Set's the address
-	api.shippingAddress = address
-	api.isAddressSet = true
-
 	// Search for the info cookie
-	var infoCookie *http.Cookie
-	for _, cookie := range api.Cookies {
-		if cookie.Name == "info" {
-			infoCookie = cookie
-		}
+	infoCookie, err := api.cookie("info")
+	if err != nil {
+		return err
 	}
 
-	if infoCookie == nil {
-		panic("Info cookie cannot be nil, something went wrong in API initialization")
-	}
+	// Set's the address
+	api.shippingAddress = address
+	api.isAddressSet = true
 
 	// Set's the address cookies correctly according to the address
 	addrRef := reflect.ValueOf(*address)
@@ -535,26 +832,27 @@ func (api *APISession) SetShippingAddress(address *ShippingAddress) bool {
 		infoCookie.Value += url.QueryEscape(field.String()) + separator
 	}
 
-	return true
+	api.logger.Info("shipping address set", slog.String("country", string(address.Country)))
+
+	return nil
 }
 
-// Clear the cart
-func (api *APISession) CartClear() {
+// Clear the cart, return's ErrSessionNotInitialized if the session cookies are missing
+func (api *APISession) CartClear() error {
 
-	// Search for the info cookie
-	var cartCookie *http.Cookie
-	for _, cookie := range api.Cookies {
-		if cookie.Name == "cart" {
-			cartCookie = cookie
-		}
-	}
-
-	if cartCookie == nil {
-		panic("Info cookie cannot be nil, something went wrong in API initialization")
+	// Search for the cart cookie
+	cartCookie, err := api.cookie("cart")
+	if err != nil {
+		return err
 	}
 
 	// Clear the cart
 	cartCookie.Value = ""
+	api.cart = nil
+
+	api.logger.Info("cart cleared")
+
+	return nil
 }
 
 // Add a product to the cart, return's nil if everything went fine or a error instead
@@ -563,27 +861,21 @@ func (api *APISession) CartClear() {
 func (api *APISession) CartAdd(p *Product, quantity uint32) error {
 
 	if p == nil {
-		return errors.New("Product cannot be nil")
+		return fmt.Errorf("%w: product cannot be nil", ErrInvalidArgument)
 	}
 
 	if quantity <= 0 {
-		return errors.New("The quantity must be > 1")
+		return fmt.Errorf("%w: the quantity must be >= 1", ErrInvalidArgument)
 	}
 
 	if p.OutOfStock {
-		return errors.New("Product cannot be out of stock")
-	}
-
-	// Search for the info cookie
-	var cartCookie *http.Cookie
-	for _, cookie := range api.Cookies {
-		if cookie.Name == "cart" {
-			cartCookie = cookie
-		}
+		return ErrOutOfStock
 	}
 
-	if cartCookie == nil {
-		panic("Info cookie cannot be nil, something went wrong in API initialization")
+	// Search for the cart cookie
+	cartCookie, err := api.cookie("cart")
+	if err != nil {
+		return err
 	}
 
 	// Construct the product for the cart and insert it after the last product inserted
@@ -602,10 +894,41 @@ func (api *APISession) CartAdd(p *Product, quantity uint32) error {
 	cartProductBuilt += fmt.Sprintf("%d", quantity) + separator
 	cartProductBuilt += fmt.Sprintf("%d", 1)
 	cartCookie.Value += url.QueryEscape(cartProductBuilt)
+	api.cart = append(api.cart, CartItem{Product: p, Quantity: quantity})
+
+	api.logger.Info("cart product added", slog.String("product_id", p.Id), slog.String("prefix", p.Prefix), slog.Uint64("quantity", uint64(quantity)))
 
 	return nil
 }
 
+// Represent a product added to the cart
+type CartItem struct {
+	// The product added
+	Product *Product
+	// The quantity added
+	Quantity uint32
+}
+
+// Return's the price of the item multiplied by its quantity
+func (item CartItem) Total() Yen {
+	return item.Product.PriceYen.Mul(int64(item.Quantity))
+}
+
+// Return's a copy of the products added to the cart
+func (api *APISession) CartItems() []CartItem {
+	return slices.Clone(api.cart)
+}
+
+// Return's the total price of the products added to the cart
+func (api *APISession) CartTotal() Yen {
+	var total Yen
+	for _, item := range api.cart {
+		total = total.Add(item.Total())
+	}
+
+	return total
+}
+
 // Contains the default checkout template constants
 const (
 	checkoutDefaultPage string = `
@@ -646,14 +969,14 @@ func (checkout *Checkout) Open() error {
 	// Insert the artisan form
 	bodyIndex := strings.Index(checkoutPage, "<body>")
 	if bodyIndex == -1 {
-		panic("Can't find body in the default constant html string, something is wrong!")
+		return errors.New("Can't find body in the default constant html string, something is wrong!")
 	}
 
 	insertPos := bodyIndex + len("<body>")
 
 	formIndex := strings.Index(checkout.pplFormData, "<form")
 	if formIndex == -1 {
-		return errors.New("Error, no form was found in the checkout")
+		return &MalformedResponseError{Reason: "no form was found in the checkout", Body: checkout.pplFormData}
 	}
 
 	// Create the new string with the insertion
@@ -665,7 +988,7 @@ func (checkout *Checkout) Open() error {
 	// Add the checkout button to the form
 	formEndIndex := strings.Index(checkoutPage, "</form>")
 	if formEndIndex == -1 {
-		return errors.New("Error, no form ending was found in the checkout")
+		return &MalformedResponseError{Reason: "no form ending was found in the checkout", Body: checkout.pplFormData}
 	}
 
 	checkoutPage = checkoutPage[:formEndIndex] + checkoutFormBtn + checkoutPage[formEndIndex:]
@@ -677,37 +1000,80 @@ func (checkout *Checkout) Open() error {
 // Create's the checkout using the product added to the cart in this session
 // it also tries to spawn a new instance of the default browser in this OS to checkout the cart
 func (api *APISession) InstanceCheckout() (*Checkout, error) {
+	return api.InstanceCheckoutCtx(context.Background())
+}
 
-	// Create's a recover for possible panics
-	defer func() {
-		if err := recover(); err != nil {
-			if api.EnableLogs {
-				fmt.Println("[Panic] -> Error sending Checkout request, panic recovered")
-			}
-			return
-		}
-	}()
+// Create's the checkout using the product added to the cart in this session, the request is cancelled as soon as the context is done
+func (api *APISession) InstanceCheckoutCtx(ctx context.Context) (*Checkout, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
 
 	// Check if the address has been set
 	if !api.isAddressSet {
-		return nil, errors.New("Error, address not set.")
+		return nil, ErrAddressNotSet
+	}
+
+	logger := api.logger.With(slog.String("operation", "InstanceCheckout"))
+
+	// Create's the checkout retrying the transient failures
+	var checkout *Checkout
+	err := api.retryPolicy.do(ctx, logRetry(ctx, logger), func(attempt int) error {
+		var err error
+		checkout, err = api.fetchCheckout(ctx, logger.With(slog.Int("attempt", attempt)))
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "request failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	// Notify the checkout in background
+	if len(api.notifiers) > 0 {
+		event := Event{Kind: CheckoutCreatedEvent, Cart: api.CartItems(), Time: time.Now()}
+
+		api.notifications.Add(1)
+		go func() {
+			defer api.notifications.Done()
+
+			notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), api.notifyTimeout)
+			defer cancel()
+
+			for _, notifier := range api.notifiers {
+				if err := notifier.Notify(notifyCtx, []Event{event}); err != nil {
+					logger.ErrorContext(notifyCtx, "notification failed", slog.String("notifier", fmt.Sprintf("%T", notifier)), slog.Any("error", err))
+				}
+			}
+		}()
 	}
 
+	return checkout, nil
+}
+
+// Wait for the checkout notifications still running in background (call it before the program exits to not lose them)
+func (api *APISession) WaitNotifications() {
+	api.notifications.Wait()
+}
+
+// Send a single nj_paypal_eng request and return the checkout received
+func (api *APISession) fetchCheckout(ctx context.Context, logger *slog.Logger) (*Checkout, error) {
+
 	// Create's a clean jar
 	jar, err := cookiejar.New(nil)
 	if err != nil {
-		return nil, errors.New("Failed to create cookie jar: " + err.Error())
+		return nil, fmt.Errorf("Failed to create cookie jar: %w", err)
 	}
 
-	// Create an HTTP client with the cookie jar
-	client := &http.Client{
-		Jar: jar,
-	}
+	// Create a copy of the session HTTP client using the clean cookie jar
+	client := *api.httpClient
+	client.Jar = jar
 
 	// Create the POST request
-	req, err := http.NewRequest("POST", APINjPaypalEng, bytes.NewBuffer([]byte(``)))
+	req, err := http.NewRequestWithContext(ctx, "POST", api.resolveURL(APINjPaypalEng), bytes.NewBuffer([]byte(``)))
 	if err != nil {
-		return nil, errors.New("Failed to create request: " + err.Error())
+		return nil, fmt.Errorf("Failed to create request: %w", err)
 	}
 
 	// Add the cookies to the request
@@ -723,16 +1089,25 @@ func (api *APISession) InstanceCheckout() (*Checkout, error) {
 	req.Header.Set("Content-Type", "text/html; charset=UTF-8")
 
 	// Send the request
-	resp, err := client.Do(req)
+	resp, err := api.send(ctx, &client, req, logger)
 	if err != nil {
-		return nil, errors.New("Failed to send request: " + err.Error())
+		return nil, fmt.Errorf("Failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Check the response status
+	if err := checkResponseStatus(resp); err != nil {
+		return nil, err
+	}
+
 	// Read the response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.New("Failed to read response body: " + err.Error())
+		return nil, fmt.Errorf("Failed to read response body: %w", err)
+	}
+
+	if len(body) == 0 {
+		return nil, &MalformedResponseError{Reason: "empty checkout response"}
 	}
 
 	return &Checkout{
@@ -741,7 +1116,65 @@ func (api *APISession) InstanceCheckout() (*Checkout, error) {
 }
 
 // Init a new api session
-func (api *APISession) initAPISession() {
+func (api *APISession) initAPISession(options APISessionOptions) {
+
+	// Set's the http client, copying the custom one if a transport has to be replaced so the caller's client is never modified
+	api.httpClient = &http.Client{}
+	if options.HTTPClient != nil {
+		api.httpClient = options.HTTPClient
+	}
+
+	if options.Transport != nil {
+		client := *api.httpClient
+		client.Transport = options.Transport
+		api.httpClient = &client
+	}
+
+	// Set's the base url
+	api.baseURL = APIDomain
+	if options.BaseURL != "" {
+		api.baseURL = strings.TrimRight(options.BaseURL, "/")
+	}
+
+	// Set's the rate limiter
+	api.rateLimiter = options.RateLimiter
+
+	// Set's the catalog
+	api.catalog = options.Catalog
+	if api.catalog == nil {
+		api.catalog = DefaultCatalog
+	}
+
+	// Set's the barcode index
+	api.barcodeIndex = options.BarcodeIndex
+
+	// Set's a copy of the notifiers
+	api.notifiers = slices.Clone(options.Notifiers)
+	api.notifyTimeout = options.NotifyTimeout
+	if api.notifyTimeout <= 0 {
+		api.notifyTimeout = DefaultNotifyTimeout
+	}
+
+	// Set's the logger discarding every record if not specified
+	api.logger = options.Logger
+	if api.logger == nil {
+		api.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	// Apply the discovery report to the catalog
+	if options.Discovery != nil {
+		if catalog, err := api.catalog.WithDiscovery(options.Discovery); err != nil {
+			api.logger.Warn("failed to apply the discovery report", slog.Any("error", err))
+		} else {
+			api.catalog = catalog
+		}
+	}
+
+	// Set's a copy of the retry policy so later changes of the caller don't affect the session
+	if options.RetryPolicy != nil {
+		retryPolicy := *options.RetryPolicy
+		api.retryPolicy = &retryPolicy
+	}
 
 	// Create fresh cookies
 	api.Cookies = []*http.Cookie{
@@ -780,6 +1213,111 @@ func (api *APISession) initAPISession() {
 	api.shippingAddress = &ShippingAddress{}
 }
 
+// Rebase an url built on APIDomain (like the api constants or MPadUrls) on the session base url
+func (api *APISession) resolveURL(apiUrl string) string {
+	if apiUrl == "" || !strings.HasPrefix(apiUrl, APIDomain) {
+		return apiUrl
+	}
+
+	return api.baseURL + strings.TrimPrefix(apiUrl, APIDomain)
+}
+
+// Waits for the rate limiter and send the request using the given client, logging the start and the end of the request
+func (api *APISession) send(ctx context.Context, client *http.Client, req *http.Request, logger *slog.Logger) (*http.Response, error) {
+	if err := api.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	logger = logger.With(slog.String("endpoint", req.URL.Path))
+	logger.DebugContext(ctx, "request started")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		logger.DebugContext(ctx, "request finished", slog.Duration("latency", time.Since(start)), slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.DebugContext(ctx, "request finished", slog.Duration("latency", time.Since(start)), slog.Int("status", resp.StatusCode))
+
+	return resp, nil
+}
+
+// Create's the callback used to log the retries with the given logger
+func logRetry(ctx context.Context, logger *slog.Logger) func(attempt int, err error, backoff time.Duration) {
+	return func(attempt int, err error, backoff time.Duration) {
+		logger.WarnContext(ctx, "request retry", slog.Int("attempt", attempt), slog.Duration("backoff", backoff), slog.Any("error", err))
+	}
+}
+
+// Return's the catalog used by the session (nil if the session has not been created with NewAPISession or NewAPISessionWithOptions)
+func (api *APISession) Catalog() *Catalog {
+	return api.catalog
+}
+
+// Discover the product pages and return's the session catalog with the offers found (the session catalog if nothing can be applied)
+func (api *APISession) discoveredCatalog(ctx context.Context) *Catalog {
+	report, err := api.DiscoverCatalog(ctx)
+	if err != nil {
+		api.logger.WarnContext(ctx, "catalog discovery failed, the session catalog offers are used", slog.Any("error", err))
+	}
+
+	if report == nil || len(report.Pages) == 0 {
+		return api.catalog
+	}
+
+	catalog, err := api.catalog.WithDiscovery(report)
+	if err != nil {
+		api.logger.WarnContext(ctx, "failed to apply the discovery report", slog.Any("error", err))
+		return api.catalog
+	}
+
+	return catalog
+}
+
+// Return's true if the mousepad is sold in the color and size according to the session catalog,
+// return's ErrSessionNotInitialized if the session has not been created with NewAPISession or NewAPISessionWithOptions
+func (api *APISession) IsOffered(pad MPad, color Color, size Size) (bool, error) {
+	if err := api.checkInitialized(); err != nil {
+		return false, err
+	}
+
+	return api.catalog.IsOffered(pad, color, size), nil
+}
+
+// Return's ErrSessionNotInitialized if the session has not been created with NewAPISession or NewAPISessionWithOptions
+func (api *APISession) checkInitialized() error {
+	if api == nil || api.httpClient == nil || api.catalog == nil {
+		return ErrSessionNotInitialized
+	}
+
+	return nil
+}
+
+// Search a session cookie by name, return's ErrSessionNotInitialized if the cookie is missing
+func (api *APISession) cookie(name string) (*http.Cookie, error) {
+	for _, cookie := range api.Cookies {
+		if cookie.Name == name {
+			return cookie, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s cookie is missing", ErrSessionNotInitialized, name)
+}
+
+// Return's an *HTTPStatusError if the response status code is not 2xx
+func checkResponseStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	return &HTTPStatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Url:        resp.Request.URL.String(),
+	}
+}
+
 // Function to check if all string properties in a struct are non-empty
 func allFieldsNonEmpty(s any) bool {
 	v := reflect.ValueOf(s)
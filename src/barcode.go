@@ -0,0 +1,242 @@
+package artisan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// *********** BARCODES ***********
+
+// The id of the in stock products is the JAN/EAN-13 barcode printed on the package like 4562332172443,
+// the last digit is a check digit computed from the other twelve
+
+// The number of digits of an EAN-13 barcode
+const ean13Length int = 13
+
+// Return's true if the code is a valid EAN-13 barcode (13 digits with a valid check digit)
+func IsValidEAN13(code string) bool {
+	return ValidateEAN13(code) == nil
+}
+
+// Validate an EAN-13 barcode, return's an ErrInvalidBarcode error with the reason if the code is not valid
+func ValidateEAN13(code string) error {
+	if len(code) != ean13Length || !isDigits(code) {
+		return fmt.Errorf("%w: %q must be made of %d digits", ErrInvalidBarcode, code, ean13Length)
+	}
+
+	if check := ean13CheckDigit(code[:ean13Length-1]); code[ean13Length-1] != check {
+		return fmt.Errorf("%w: %q has check digit %c, expected %c", ErrInvalidBarcode, code, code[ean13Length-1], check)
+	}
+
+	return nil
+}
+
+// Compute the check digit of the first twelve digits of an EAN-13 barcode (the digits in even positions weight 3)
+func ean13CheckDigit(digits string) byte {
+	sum := 0
+	for i, c := range []byte(digits) {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+
+		sum += int(c-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+// Represent a barcode seen in a product details response
+type BarcodeEntry struct {
+	// The EAN-13 barcode (the product id)
+	Barcode string
+	// The product combination the barcode belongs to
+	ProductDetailsBody
+	// The product prefix/sku
+	Prefix string
+	// The product full name
+	Name string
+	// When the barcode has been seen for the last time
+	LastSeen time.Time
+}
+
+// Represent a local index of the barcodes seen in the past scans, it's safe for concurrent use.
+// Set it in APISessionOptions.BarcodeIndex to record every in stock product fetched by the session
+type BarcodeIndex struct {
+	mu      sync.RWMutex
+	entries map[string]BarcodeEntry
+}
+
+// Create's a new empty barcode index
+func NewBarcodeIndex() *BarcodeIndex {
+	return &BarcodeIndex{entries: map[string]BarcodeEntry{}}
+}
+
+// Contains the version of the barcode index file format
+const barcodeIndexVersion int = 1
+
+// Represent the json file of a barcode index (the ids are kept raw so the file doesn't depend on the catalog names)
+type barcodeIndexFile struct {
+	Version int                    `json:"version"`
+	Entries []barcodeIndexFileItem `json:"entries"`
+}
+
+type barcodeIndexFileItem struct {
+	Barcode  string    `json:"barcode"`
+	SirID    string    `json:"sir_id"`
+	SizeID   string    `json:"size_id"`
+	ColorID  string    `json:"color_id"`
+	Prefix   string    `json:"prefix,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Load a barcode index saved with Save, a missing file is not an error and return's an empty index
+func LoadBarcodeIndex(path string) (*BarcodeIndex, error) {
+	index := NewBarcodeIndex()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return index, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file barcodeIndexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("Failed to decode barcode index %s: %w", path, err)
+	}
+
+	if file.Version != barcodeIndexVersion {
+		return nil, fmt.Errorf("Failed to decode barcode index %s: unsupported version %d", path, file.Version)
+	}
+
+	for _, item := range file.Entries {
+		if err := ValidateEAN13(item.Barcode); err != nil {
+			return nil, fmt.Errorf("Failed to decode barcode index %s: %w", path, err)
+		}
+
+		index.entries[item.Barcode] = BarcodeEntry{
+			Barcode:            item.Barcode,
+			ProductDetailsBody: ProductDetailsBody{SirID: MPad(item.SirID), SizeID: Size(item.SizeID), ColorID: Color(item.ColorID)},
+			Prefix:             item.Prefix,
+			Name:               item.Name,
+			LastSeen:           item.LastSeen,
+		}
+	}
+
+	return index, nil
+}
+
+// Save the index in a json file, the file is replaced atomically so a concurrent LoadBarcodeIndex never reads a partial file
+func (index *BarcodeIndex) Save(path string) error {
+	file := barcodeIndexFile{Version: barcodeIndexVersion, Entries: []barcodeIndexFileItem{}}
+	for _, entry := range index.Entries() {
+		file.Entries = append(file.Entries, barcodeIndexFileItem{
+			Barcode:  entry.Barcode,
+			SirID:    string(entry.SirID),
+			SizeID:   string(entry.SizeID),
+			ColorID:  string(entry.ColorID),
+			Prefix:   entry.Prefix,
+			Name:     entry.Name,
+			LastSeen: entry.LastSeen,
+		})
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Record the barcode of the product, return's an ErrInvalidArgument error for nil or out of stock products
+// and an ErrInvalidBarcode error if the product id is not a valid EAN-13 barcode
+func (index *BarcodeIndex) Add(p *Product) error {
+	if p == nil || p.ProductDetailsBody == nil {
+		return fmt.Errorf("%w: product and it's details body cannot be nil", ErrInvalidArgument)
+	}
+
+	if p.OutOfStock {
+		return fmt.Errorf("%w: out of stock products have no barcode", ErrInvalidArgument)
+	}
+
+	if err := ValidateEAN13(p.Id); err != nil {
+		return err
+	}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+
+	index.entries[p.Id] = BarcodeEntry{
+		Barcode:            p.Id,
+		ProductDetailsBody: *p.ProductDetailsBody,
+		Prefix:             p.Prefix,
+		Name:               p.FullName,
+		LastSeen:           time.Now(),
+	}
+
+	return nil
+}
+
+// Return's the product combination of the barcode and true if the barcode has been seen, otherwise false
+func (index *BarcodeIndex) Lookup(code string) (ProductDetailsBody, bool) {
+	entry, ok := index.Entry(code)
+	return entry.ProductDetailsBody, ok
+}
+
+// Return's the entry of the barcode and true if the barcode has been seen, otherwise false
+func (index *BarcodeIndex) Entry(code string) (BarcodeEntry, bool) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	entry, ok := index.entries[code]
+	return entry, ok
+}
+
+// Return's every entry of the index sorted by barcode
+func (index *BarcodeIndex) Entries() []BarcodeEntry {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	entries := make([]BarcodeEntry, 0, len(index.entries))
+	for _, entry := range index.entries {
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b BarcodeEntry) int { return strings.Compare(a.Barcode, b.Barcode) })
+
+	return entries
+}
+
+// Return's the number of barcodes in the index
+func (index *BarcodeIndex) Len() int {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	return len(index.entries)
+}
@@ -0,0 +1,704 @@
+package artisan
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+)
+
+// *********** CATALOG ***********
+
+// Represent an artisan mousepad series (every series comes in one or more hardness)
+type Series string
+
+// Contains all the available series
+const (
+	ZeroSeries       Series = "Zero"
+	RaidenSeries     Series = "Raiden"
+	HayateOtsuSeries Series = "HayateOtsu"
+	HayateKouSeries  Series = "HayateKou"
+	HienSeries       Series = "Hien"
+	Type99Series     Series = "Type99"
+	ShidenkaiSeries  Series = "Shidenkai"
+)
+
+// Represent the hardness of a mousepad sponge
+type Hardness string
+
+// Contains all the available hardness
+const (
+	XSoftHardness Hardness = "XSOFT"
+	SoftHardness  Hardness = "SOFT"
+	MidHardness   Hardness = "MID"
+)
+
+// Contains all the known hardness values
+var Hardnesses = []Hardness{XSoftHardness, SoftHardness, MidHardness}
+
+// Represent an artisan product line
+type Line string
+
+// Contains all the available lines
+const (
+	FXLine      Line = "FX"
+	ClassicLine Line = "CS"
+)
+
+// Represent a single mousepad of the catalog (a series in a line with a hardness)
+type Model struct {
+	// The series of the mousepad (the classic variants belong to the same series of the FX ones)
+	Series Series `json:"series"`
+	// The line of the mousepad
+	Line Line `json:"line"`
+	// The hardness of the mousepad sponge
+	Hardness Hardness `json:"hardness"`
+	// The id used by the website to identify the mousepad
+	SirID MPad `json:"sir_id"`
+	// The url of the mousepad page
+	URL string `json:"url"`
+	// The color/size combinations the mousepad is sold in (if empty every catalog color and size combination is considered offered),
+	// it can be declared or filled with the discovered ones thru Catalog.WithDiscovery
+	Offers []Variant `json:"offers,omitempty"`
+	// If true the mousepad is not sold anymore and no combination is considered offered (it must not have Offers),
+	// Catalog.WithDiscovery set's it for the models missing in their page
+	Discontinued bool `json:"discontinued,omitempty"`
+	// Additional names accepted by ParseMPad (the series/line/hardness combinations are generated automatically)
+	Aliases []string `json:"aliases,omitempty"`
+	// The sku prefix of the model like "FX-HI-XS" (if empty it's built from the line, series and hardness codes)
+	SKU string `json:"sku,omitempty"`
+}
+
+// Represent a color/size combination of a mousepad
+type Variant struct {
+	Color Color `json:"color"`
+	Size  Size  `json:"size"`
+}
+
+// Represent a color of the catalog
+type ColorDefinition struct {
+	// The id used by the website to identify the color
+	ID Color `json:"id"`
+	// The color name
+	Name string `json:"name"`
+	// Additional names accepted by ParseColor
+	Aliases []string `json:"aliases,omitempty"`
+	// The code of the color in the skus like "R" (the colors without a code can't be encoded in a sku)
+	SKUCode string `json:"sku_code,omitempty"`
+}
+
+// Represent a size of the catalog
+type SizeDefinition struct {
+	// The id used by the website to identify the size
+	ID Size `json:"id"`
+	// The size name
+	Name string `json:"name"`
+	// Additional names accepted by ParseSize
+	Aliases []string `json:"aliases,omitempty"`
+	// The code of the size in the skus (if empty the size name is used)
+	SKUCode string `json:"sku_code,omitempty"`
+}
+
+// The catalog definition version supported by this library
+const CatalogVersion int = 1
+
+// Represent the definition of a catalog, it's also the format of the catalog data files (see LoadCatalogFile)
+type CatalogDefinition struct {
+	// The version of the definition format, it must be CatalogVersion
+	Version int `json:"version"`
+	// If true the definition replaces the built-in catalog instead of extending it (only used by the data files)
+	Replace bool `json:"replace,omitempty"`
+	// All the mousepads
+	Models []Model `json:"models"`
+	// All the colors
+	Colors []ColorDefinition `json:"colors"`
+	// All the sizes
+	Sizes []SizeDefinition `json:"sizes"`
+}
+
+// Represent a catalog of mousepads, colors and sizes, it's immutable once created so it can be shared between goroutines
+type Catalog struct {
+	// The definition the catalog has been created from
+	definition CatalogDefinition
+	// The index of every model by sir id
+	bySirID map[MPad]int
+	// The index of every color by id
+	byColor map[Color]int
+	// The index of every size by id
+	bySize map[Size]int
+	// The offered combinations of every model with declared offers (empty for the discontinued ones)
+	offers map[MPad]map[Variant]bool
+	// The name lookup tables used by ParseMPad, ParseColor and ParseSize
+	mpadNames      *nameIndex
+	colorNameIndex *nameIndex
+	sizeNameIndex  *nameIndex
+	// The sku lookup tables used by DecodeSKU and EncodeSKU
+	skuModels map[string]MPad
+	skuColors map[string]Color
+	skuSizes  map[string]Size
+}
+
+// Represent the json format of a CatalogDefinition, the ids are kept as plain strings so the data files can add ids that are not
+// in the DefaultCatalog (MPad, Color and Size resolve the names against it)
+type catalogFile struct {
+	Version int                `json:"version"`
+	Replace bool               `json:"replace,omitempty"`
+	Models  []catalogFileModel `json:"models"`
+	Colors  []catalogFileEntry `json:"colors"`
+	Sizes   []catalogFileEntry `json:"sizes"`
+}
+
+type catalogFileModel struct {
+	Series       Series               `json:"series"`
+	Line         Line                 `json:"line"`
+	Hardness     Hardness             `json:"hardness"`
+	SirID        string               `json:"sir_id"`
+	URL          string               `json:"url"`
+	Offers       []catalogFileVariant `json:"offers,omitempty"`
+	Discontinued bool                 `json:"discontinued,omitempty"`
+	Aliases      []string             `json:"aliases,omitempty"`
+	SKU          string               `json:"sku,omitempty"`
+}
+
+type catalogFileVariant struct {
+	Color string `json:"color"`
+	Size  string `json:"size"`
+}
+
+type catalogFileEntry struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	SKUCode string   `json:"sku_code,omitempty"`
+}
+
+// Marshal the definition in the catalog data files format (the ids are always raw site ids)
+func (definition CatalogDefinition) MarshalJSON() ([]byte, error) {
+	file := catalogFile{
+		Version: definition.Version,
+		Replace: definition.Replace,
+		Models:  make([]catalogFileModel, 0, len(definition.Models)),
+		Colors:  make([]catalogFileEntry, 0, len(definition.Colors)),
+		Sizes:   make([]catalogFileEntry, 0, len(definition.Sizes)),
+	}
+
+	for _, model := range definition.Models {
+		var offers []catalogFileVariant
+		for _, variant := range model.Offers {
+			offers = append(offers, catalogFileVariant{Color: string(variant.Color), Size: string(variant.Size)})
+		}
+
+		file.Models = append(file.Models, catalogFileModel{
+			Series:       model.Series,
+			Line:         model.Line,
+			Hardness:     model.Hardness,
+			SirID:        string(model.SirID),
+			URL:          model.URL,
+			Offers:       offers,
+			Discontinued: model.Discontinued,
+			Aliases:      model.Aliases,
+			SKU:          model.SKU,
+		})
+	}
+
+	for _, color := range definition.Colors {
+		file.Colors = append(file.Colors, catalogFileEntry{ID: string(color.ID), Name: color.Name, Aliases: color.Aliases, SKUCode: color.SKUCode})
+	}
+
+	for _, size := range definition.Sizes {
+		file.Sizes = append(file.Sizes, catalogFileEntry{ID: string(size.ID), Name: size.Name, Aliases: size.Aliases, SKUCode: size.SKUCode})
+	}
+
+	return json.Marshal(file)
+}
+
+// Unmarshal the definition from the catalog data files format, the ids must be raw site ids (they are validated by NewCatalog)
+func (definition *CatalogDefinition) UnmarshalJSON(data []byte) error {
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	*definition = CatalogDefinition{Version: file.Version, Replace: file.Replace}
+
+	for _, model := range file.Models {
+		var offers []Variant
+		for _, variant := range model.Offers {
+			offers = append(offers, Variant{Color: Color(variant.Color), Size: Size(variant.Size)})
+		}
+
+		definition.Models = append(definition.Models, Model{
+			Series:       model.Series,
+			Line:         model.Line,
+			Hardness:     model.Hardness,
+			SirID:        MPad(model.SirID),
+			URL:          model.URL,
+			Offers:       offers,
+			Discontinued: model.Discontinued,
+			Aliases:      model.Aliases,
+			SKU:          model.SKU,
+		})
+	}
+
+	for _, color := range file.Colors {
+		definition.Colors = append(definition.Colors, ColorDefinition{ID: Color(color.ID), Name: color.Name, Aliases: color.Aliases, SKUCode: color.SKUCode})
+	}
+
+	for _, size := range file.Sizes {
+		definition.Sizes = append(definition.Sizes, SizeDefinition{ID: Size(size.ID), Name: size.Name, Aliases: size.Aliases, SKUCode: size.SKUCode})
+	}
+
+	return nil
+}
+
+// Create's a new catalog from the given definition, return's an ErrInvalidCatalog error if the version is not supported or
+// an entry is incomplete, duplicated or has an invalid url
+func NewCatalog(definition CatalogDefinition) (*Catalog, error) {
+	if definition.Version != CatalogVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d (expected %d)", ErrInvalidCatalog, definition.Version, CatalogVersion)
+	}
+
+	catalog := &Catalog{
+		definition: CatalogDefinition{
+			Version: definition.Version,
+			Models:  cloneModels(definition.Models),
+			Colors:  slices.Clone(definition.Colors),
+			Sizes:   slices.Clone(definition.Sizes),
+		},
+		bySirID: make(map[MPad]int, len(definition.Models)),
+		byColor: make(map[Color]int, len(definition.Colors)),
+		bySize:  make(map[Size]int, len(definition.Sizes)),
+		offers:  map[MPad]map[Variant]bool{},
+	}
+
+	for i, model := range catalog.definition.Models {
+		if model.SirID == "" || model.Series == "" || model.Line == "" || model.Hardness == "" || model.URL == "" {
+			return nil, fmt.Errorf("%w: model %d (sir id %q) has empty fields", ErrInvalidCatalog, i, string(model.SirID))
+		}
+
+		if modelUrl, err := url.Parse(model.URL); err != nil || !modelUrl.IsAbs() {
+			return nil, fmt.Errorf("%w: model %q has an invalid url %q", ErrInvalidCatalog, string(model.SirID), model.URL)
+		}
+
+		if _, ok := catalog.bySirID[model.SirID]; ok {
+			return nil, fmt.Errorf("%w: duplicated sir id %q", ErrInvalidCatalog, string(model.SirID))
+		}
+
+		catalog.bySirID[model.SirID] = i
+
+		// A discontinued model is known but offered in no combination
+		if model.Discontinued {
+			if len(model.Offers) > 0 {
+				return nil, fmt.Errorf("%w: model %q is discontinued but has offers", ErrInvalidCatalog, string(model.SirID))
+			}
+
+			catalog.offers[model.SirID] = map[Variant]bool{}
+			continue
+		}
+
+		if len(model.Offers) == 0 {
+			continue
+		}
+
+		catalog.offers[model.SirID] = make(map[Variant]bool, len(model.Offers))
+		for _, variant := range model.Offers {
+			if variant.Color == "" || variant.Size == "" {
+				return nil, fmt.Errorf("%w: model %q has an offer with empty fields", ErrInvalidCatalog, string(model.SirID))
+			}
+
+			catalog.offers[model.SirID][variant] = true
+		}
+	}
+
+	for i, color := range catalog.definition.Colors {
+		if color.ID == "" || color.Name == "" {
+			return nil, fmt.Errorf("%w: color %d (id %q) has empty fields", ErrInvalidCatalog, i, string(color.ID))
+		}
+
+		if _, ok := catalog.byColor[color.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicated color id %q", ErrInvalidCatalog, string(color.ID))
+		}
+
+		catalog.byColor[color.ID] = i
+	}
+
+	for i, size := range catalog.definition.Sizes {
+		if size.ID == "" || size.Name == "" {
+			return nil, fmt.Errorf("%w: size %d (id %q) has empty fields", ErrInvalidCatalog, i, string(size.ID))
+		}
+
+		if _, ok := catalog.bySize[size.ID]; ok {
+			return nil, fmt.Errorf("%w: duplicated size id %q", ErrInvalidCatalog, string(size.ID))
+		}
+
+		catalog.bySize[size.ID] = i
+	}
+
+	catalog.buildNameIndexes()
+
+	if err := catalog.buildSKUIndexes(); err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+// Create's a new catalog with the entries of this catalog extended by the ones of the definition, the entries with the same id
+// are overridden while the new ones are appended (if definition.Replace is true the definition entries are used alone)
+func (catalog *Catalog) Extend(definition CatalogDefinition) (*Catalog, error) {
+	if definition.Replace {
+		return NewCatalog(definition)
+	}
+
+	if definition.Version != CatalogVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d (expected %d)", ErrInvalidCatalog, definition.Version, CatalogVersion)
+	}
+
+	return NewCatalog(CatalogDefinition{
+		Version: CatalogVersion,
+		Models:  mergeDefinitions(catalog.definition.Models, definition.Models, func(model Model) MPad { return model.SirID }),
+		Colors:  mergeDefinitions(catalog.definition.Colors, definition.Colors, func(color ColorDefinition) Color { return color.ID }),
+		Sizes:   mergeDefinitions(catalog.definition.Sizes, definition.Sizes, func(size SizeDefinition) Size { return size.ID }),
+	})
+}
+
+// Load a catalog data file (a json CatalogDefinition with raw site ids) extending or replacing the DefaultCatalog
+func LoadCatalogFile(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition CatalogDefinition
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCatalog, path, err)
+	}
+
+	catalog, err := DefaultCatalog.Extend(definition)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return catalog, nil
+}
+
+// Return's a copy of the definition of the catalog
+func (catalog *Catalog) Definition() CatalogDefinition {
+	return CatalogDefinition{
+		Version: catalog.definition.Version,
+		Models:  cloneModels(catalog.definition.Models),
+		Colors:  slices.Clone(catalog.definition.Colors),
+		Sizes:   slices.Clone(catalog.definition.Sizes),
+	}
+}
+
+// Return's a copy of all the models of the catalog
+func (catalog *Catalog) Models() []Model {
+	return cloneModels(catalog.definition.Models)
+}
+
+// Search a model by sir id
+func (catalog *Catalog) Model(pad MPad) (Model, bool) {
+	i, ok := catalog.bySirID[pad]
+	if !ok {
+		return Model{}, false
+	}
+
+	return catalog.definition.Models[i], true
+}
+
+// Return's the url of the model page (empty if the model is not in the catalog)
+func (catalog *Catalog) URL(pad MPad) string {
+	model, _ := catalog.Model(pad)
+	return model.URL
+}
+
+// Return's true if the mousepad is sold in the color and size, the models without declared offers are considered offered
+// in every catalog color and size while the discontinued ones are never offered
+func (catalog *Catalog) IsOffered(pad MPad, color Color, size Size) bool {
+	if _, ok := catalog.bySirID[pad]; !ok {
+		return false
+	}
+
+	if offers, ok := catalog.offers[pad]; ok {
+		return offers[Variant{Color: color, Size: size}]
+	}
+
+	_, colorOk := catalog.byColor[color]
+	_, sizeOk := catalog.bySize[size]
+
+	return colorOk && sizeOk
+}
+
+// Return's true if the mousepad is sold in the color and size according to the DefaultCatalog
+func IsOffered(pad MPad, color Color, size Size) bool {
+	return DefaultCatalog.IsOffered(pad, color, size)
+}
+
+// Create's a new catalog with the offers of every mousepad replaced by the color/size combinations found in its page by the discovery.
+// The pages list the colors and sizes of all the mousepads they contain, so every mousepad of a page gets all the combinations of the page,
+// the mousepads missing in the sir select of their page are marked as discontinued
+func (catalog *Catalog) WithDiscovery(report *DiscoveryReport) (*Catalog, error) {
+	definition := catalog.Definition()
+
+	for _, page := range report.Pages {
+		// Build every combination of the page
+		var offers []Variant
+		for _, color := range page.Colors {
+			for _, size := range page.Sizes {
+				offers = append(offers, Variant{Color: Color(color.ID), Size: Size(size.ID)})
+			}
+		}
+
+		for i, model := range definition.Models {
+			if model.URL != page.URL {
+				continue
+			}
+
+			// The pages without a sir select have a single mousepad, it's always listed
+			listed := len(page.MPads) == 0 || slices.ContainsFunc(page.MPads, func(option DiscoveredOption) bool { return option.ID == string(model.SirID) })
+			if !listed {
+				definition.Models[i].Offers = nil
+				definition.Models[i].Discontinued = true
+				continue
+			}
+
+			if len(offers) > 0 {
+				definition.Models[i].Offers = slices.Clone(offers)
+				definition.Models[i].Discontinued = false
+			}
+		}
+	}
+
+	return NewCatalog(definition)
+}
+
+// Return's all the color ids of the catalog
+func (catalog *Catalog) Colors() []Color {
+	colors := make([]Color, len(catalog.definition.Colors))
+	for i, color := range catalog.definition.Colors {
+		colors[i] = color.ID
+	}
+
+	return colors
+}
+
+// Return's the name of the color (empty if the color is not in the catalog)
+func (catalog *Catalog) ColorName(color Color) string {
+	i, ok := catalog.byColor[color]
+	if !ok {
+		return ""
+	}
+
+	return catalog.definition.Colors[i].Name
+}
+
+// Return's all the size ids of the catalog
+func (catalog *Catalog) Sizes() []Size {
+	sizes := make([]Size, len(catalog.definition.Sizes))
+	for i, size := range catalog.definition.Sizes {
+		sizes[i] = size.ID
+	}
+
+	return sizes
+}
+
+// Return's the name of the size (empty if the size is not in the catalog)
+func (catalog *Catalog) SizeName(size Size) string {
+	i, ok := catalog.bySize[size]
+	if !ok {
+		return ""
+	}
+
+	return catalog.definition.Sizes[i].Name
+}
+
+// Return's all the models of the given series
+func (catalog *Catalog) BySeries(series Series) []Model {
+	return catalog.filter(func(model Model) bool { return model.Series == series })
+}
+
+// Return's all the models with the given hardness
+func (catalog *Catalog) ByHardness(hardness Hardness) []Model {
+	return catalog.filter(func(model Model) bool { return model.Hardness == hardness })
+}
+
+// Return's all the models of the given line
+func (catalog *Catalog) ByLine(line Line) []Model {
+	return catalog.filter(func(model Model) bool { return model.Line == line })
+}
+
+// Return's the sir ids of all the models
+func (catalog *Catalog) MPads() []MPad {
+	pads := make([]MPad, len(catalog.definition.Models))
+	for i, model := range catalog.definition.Models {
+		pads[i] = model.SirID
+	}
+
+	return pads
+}
+
+// Return's the models matching the predicate
+func (catalog *Catalog) filter(predicate func(Model) bool) []Model {
+	var models []Model
+	for _, model := range catalog.definition.Models {
+		if predicate(model) {
+			models = append(models, model)
+		}
+	}
+
+	return models
+}
+
+// Return's the url of every model
+func (catalog *Catalog) urls() map[MPad]string {
+	urls := make(map[MPad]string, len(catalog.definition.Models))
+	for _, model := range catalog.definition.Models {
+		urls[model.SirID] = model.URL
+	}
+
+	return urls
+}
+
+// Return's the series of every model
+func (catalog *Catalog) series() map[MPad]Series {
+	series := make(map[MPad]Series, len(catalog.definition.Models))
+	for _, model := range catalog.definition.Models {
+		series[model.SirID] = model.Series
+	}
+
+	return series
+}
+
+// Return's the hardness of every model
+func (catalog *Catalog) hardness() map[MPad]Hardness {
+	hardness := make(map[MPad]Hardness, len(catalog.definition.Models))
+	for _, model := range catalog.definition.Models {
+		hardness[model.SirID] = model.Hardness
+	}
+
+	return hardness
+}
+
+// Return's the name of every color
+func (catalog *Catalog) colorNames() map[Color]string {
+	names := make(map[Color]string, len(catalog.definition.Colors))
+	for _, color := range catalog.definition.Colors {
+		names[color.ID] = color.Name
+	}
+
+	return names
+}
+
+// Return's the name of every size
+func (catalog *Catalog) sizeNames() map[Size]string {
+	names := make(map[Size]string, len(catalog.definition.Sizes))
+	for _, size := range catalog.definition.Sizes {
+		names[size.ID] = size.Name
+	}
+
+	return names
+}
+
+// Return's a deep copy of the models (offers included) so the catalog can't be modified from outside
+func cloneModels(models []Model) []Model {
+	cloned := slices.Clone(models)
+	for i := range cloned {
+		cloned[i].Offers = slices.Clone(cloned[i].Offers)
+		cloned[i].Aliases = slices.Clone(cloned[i].Aliases)
+	}
+
+	return cloned
+}
+
+// Merge two lists of definitions, the extensions with an id already in base override it while the others are appended
+func mergeDefinitions[T any, K comparable](base []T, extensions []T, id func(T) K) []T {
+	merged := slices.Clone(base)
+
+	for _, extension := range extensions {
+		i := slices.IndexFunc(merged, func(entry T) bool { return id(entry) == id(extension) })
+		if i == -1 {
+			merged = append(merged, extension)
+		} else {
+			merged[i] = extension
+		}
+	}
+
+	return merged
+}
+
+// Search the model of the mousepad in the DefaultCatalog
+func (pad MPad) Model() (Model, bool) {
+	return DefaultCatalog.Model(pad)
+}
+
+// The catalog with all the built-in mousepads, colors and sizes, adding an entry here is enough to make it available everywhere
+var DefaultCatalog = mustNewCatalog(CatalogDefinition{
+	Version: CatalogVersion,
+	Models:  defaultModels,
+	Colors: []ColorDefinition{
+		{ID: WineRedColor, Name: "WineRed", Aliases: []string{"red"}, SKUCode: "R"},
+		{ID: NinjaBlackColor, Name: "NinjaBlack", Aliases: []string{"ninja"}, SKUCode: "N"},
+		{ID: BlackColor, Name: "Black", SKUCode: "B"},
+		{ID: SnowWhiteColor, Name: "SnowWhite", Aliases: []string{"white"}, SKUCode: "W"},
+		{ID: CoffeeBrownColor, Name: "CoffeeBrown", Aliases: []string{"brown"}, SKUCode: "C"},
+		{ID: DaidaiOrangeColor, Name: "Daidai Orange", Aliases: []string{"orange", "daidai"}, SKUCode: "O"},
+		{ID: MatchaGreenColor, Name: "MatchaGreen", Aliases: []string{"green", "matcha"}, SKUCode: "G"},
+		{ID: GrayColor, Name: "Gray", Aliases: []string{"grey"}, SKUCode: "GY"},
+	},
+	Sizes: []SizeDefinition{
+		{ID: SizeS, Name: "S", Aliases: []string{"small"}},
+		{ID: SizeM, Name: "M", Aliases: []string{"medium"}},
+		{ID: SizeL, Name: "L", Aliases: []string{"large"}},
+		{ID: SizeXL, Name: "XL", Aliases: []string{"xlarge", "extra large"}},
+		{ID: SizeXXL, Name: "XXL", Aliases: []string{"2xl", "xxlarge"}},
+	},
+})
+
+// Contains all the built-in mousepads
+var defaultModels = []Model{
+	{Series: ZeroSeries, Line: ClassicLine, Hardness: XSoftHardness, SirID: ZeroClassicXSoftMPad, URL: APIDomain + "/cs-zero-eng.html"},
+	{Series: ZeroSeries, Line: ClassicLine, Hardness: SoftHardness, SirID: ZeroClassicSoftMPad, URL: APIDomain + "/cs-zero-eng.html"},
+	{Series: ZeroSeries, Line: ClassicLine, Hardness: MidHardness, SirID: ZeroClassicMidMPad, URL: APIDomain + "/cs-zero-eng.html"},
+
+	{Series: RaidenSeries, Line: ClassicLine, Hardness: XSoftHardness, SirID: RaidenClassicXSoftMPad, URL: APIDomain + "/cs-raiden-eng.html"},
+	{Series: RaidenSeries, Line: ClassicLine, Hardness: MidHardness, SirID: RaidenClassicMidMPad, URL: APIDomain + "/cs-raiden-eng.html"},
+
+	{Series: HayateOtsuSeries, Line: FXLine, Hardness: XSoftHardness, SirID: HayateOtsuXSoftMPad, URL: APIDomain + "/fx-hayate-otsu-eng.html"},
+	{Series: HayateOtsuSeries, Line: FXLine, Hardness: SoftHardness, SirID: HayateOtsuSoftMPad, URL: APIDomain + "/fx-hayate-otsu-eng.html"},
+	{Series: HayateOtsuSeries, Line: FXLine, Hardness: MidHardness, SirID: HayateOtsuMidMPad, URL: APIDomain + "/fx-hayate-otsu-eng.html"},
+
+	{Series: HayateKouSeries, Line: FXLine, Hardness: XSoftHardness, SirID: HayateKouXSoftMPad, URL: APIDomain + "/fx-hayate-kou-eng.html"},
+	{Series: HayateKouSeries, Line: FXLine, Hardness: SoftHardness, SirID: HayateKouSoftMPad, URL: APIDomain + "/fx-hayate-kou-eng.html"},
+	{Series: HayateKouSeries, Line: FXLine, Hardness: MidHardness, SirID: HayateKouMidMPad, URL: APIDomain + "/fx-hayate-kou-eng.html"},
+
+	{Series: HienSeries, Line: FXLine, Hardness: XSoftHardness, SirID: HienXSoftMPad, URL: APIDomain + "/fx-hien-eng.html"},
+	{Series: HienSeries, Line: FXLine, Hardness: SoftHardness, SirID: HienSoftMPad, URL: APIDomain + "/fx-hien-eng.html"},
+	{Series: HienSeries, Line: FXLine, Hardness: MidHardness, SirID: HienMidMPad, URL: APIDomain + "/fx-hien-eng.html"},
+
+	{Series: ZeroSeries, Line: FXLine, Hardness: XSoftHardness, SirID: ZeroXSoftMPad, URL: APIDomain + "/fx-zero-eng.html"},
+	{Series: ZeroSeries, Line: FXLine, Hardness: SoftHardness, SirID: ZeroSoftMPad, URL: APIDomain + "/fx-zero-eng.html"},
+	{Series: ZeroSeries, Line: FXLine, Hardness: MidHardness, SirID: ZeroMidMPad, URL: APIDomain + "/fx-zero-eng.html"},
+
+	{Series: RaidenSeries, Line: FXLine, Hardness: XSoftHardness, SirID: RaidenXSoftMPad, URL: APIDomain + "/fx-raiden-eng.html"},
+	{Series: RaidenSeries, Line: FXLine, Hardness: SoftHardness, SirID: RaidenSoftMPad, URL: APIDomain + "/fx-raiden-eng.html"},
+	{Series: RaidenSeries, Line: FXLine, Hardness: MidHardness, SirID: RaidenMidMPad, URL: APIDomain + "/fx-raiden-eng.html"},
+
+	{Series: Type99Series, Line: FXLine, Hardness: XSoftHardness, SirID: Type99XSoftMPad, URL: APIDomain + "/fx-99-eng.html"},
+	{Series: Type99Series, Line: FXLine, Hardness: SoftHardness, SirID: Type99SoftMPad, URL: APIDomain + "/fx-99-eng.html"},
+	{Series: Type99Series, Line: FXLine, Hardness: MidHardness, SirID: Type99MidMPad, URL: APIDomain + "/fx-99-eng.html"},
+
+	{Series: ShidenkaiSeries, Line: FXLine, Hardness: XSoftHardness, SirID: ShidenkaiV2XSoftMPad, URL: APIDomain + "/fx-shidenkai-eng.html"},
+	{Series: ShidenkaiSeries, Line: FXLine, Hardness: MidHardness, SirID: ShidenkaiV2MidMPad, URL: APIDomain + "/fx-shidenkai-eng.html"},
+}
+
+// Create's a new catalog panicking if the definition is invalid (used only for the built-in catalog)
+func mustNewCatalog(definition CatalogDefinition) *Catalog {
+	catalog, err := NewCatalog(definition)
+	if err != nil {
+		panic(err)
+	}
+
+	return catalog
+}
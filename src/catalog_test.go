@@ -0,0 +1,161 @@
+package artisan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Load a catalog file adding a new mousepad, color and size that are not in the DefaultCatalog
+func TestLoadCatalogFileNewEntries(t *testing.T) {
+	data := `{
+		"version": 1,
+		"models": [{
+			"series": "Hien", "line": "FX", "hardness": "MID", "sir_id": "999", "url": "https://www.artisan-jp.com/fx-hien-eng.html",
+			"offers": [{"color": "20", "size": "9"}], "aliases": ["hien v2"], "sku": "FX-HI2-M"
+		}],
+		"colors": [{"id": "20", "name": "Sakura Pink", "sku_code": "P"}],
+		"sizes": [{"id": "9", "name": "XXXL"}]
+	}`
+
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := LoadCatalogFile(path)
+	if err != nil {
+		t.Fatalf("LoadCatalogFile failed: %v", err)
+	}
+
+	if _, ok := catalog.Model("999"); !ok {
+		t.Fatal("the new mousepad is not in the catalog")
+	}
+
+	if name := catalog.ColorName("20"); name != "Sakura Pink" {
+		t.Fatalf("got color name %q, expected %q", name, "Sakura Pink")
+	}
+
+	if name := catalog.SizeName("9"); name != "XXXL" {
+		t.Fatalf("got size name %q, expected %q", name, "XXXL")
+	}
+
+	if !catalog.IsOffered("999", "20", "9") || catalog.IsOffered("999", WineRedColor, SizeXL) {
+		t.Fatal("the declared offers of the new mousepad are not used")
+	}
+
+	if pad, err := catalog.ParseMPad("hien v2"); err != nil || pad != "999" {
+		t.Fatalf("got %q, %v parsing the new mousepad alias", pad, err)
+	}
+
+	// The built-in entries are kept
+	if _, ok := catalog.Model(HienMidMPad); !ok {
+		t.Fatal("the built-in mousepads are missing")
+	}
+
+	// The definition is encoded with the raw ids so it can be loaded again
+	encoded, err := json.Marshal(catalog.Definition())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var definition CatalogDefinition
+	if err := json.Unmarshal(encoded, &definition); err != nil {
+		t.Fatalf("failed to decode the encoded definition: %v", err)
+	}
+
+	if _, err := NewCatalog(definition); err != nil {
+		t.Fatalf("the encoded definition is invalid: %v", err)
+	}
+
+	if definition.Models[0].SirID != ZeroClassicXSoftMPad {
+		t.Fatalf("got sir id %q, expected the raw id %q", definition.Models[0].SirID, ZeroClassicXSoftMPad)
+	}
+}
+
+// The bodies with ids that are not in the DefaultCatalog (like the ones added by a catalog file) round-trip thru json
+func TestProductDetailsBodyJSONRawIDs(t *testing.T) {
+	for _, body := range []ProductDetailsBody{
+		{SirID: HienMidMPad, SizeID: SizeXL, ColorID: WineRedColor},
+		{SirID: "999", SizeID: "9", ColorID: "20"},
+		{},
+	} {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ProductDetailsBody
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("failed to decode %s: %v", encoded, err)
+		}
+
+		if decoded != body {
+			t.Fatalf("got %+v from %s, expected %+v", decoded, encoded, body)
+		}
+	}
+}
+
+// The mousepads missing in the sir select of their page are never offered after the discovery
+func TestWithDiscoveryDiscontinued(t *testing.T) {
+	report := &DiscoveryReport{Pages: []DiscoveredPage{{
+		URL:    DefaultCatalog.URL(HienMidMPad),
+		MPads:  []DiscoveredOption{{ID: string(HienXSoftMPad)}, {ID: string(HienSoftMPad)}},
+		Colors: []DiscoveredOption{{ID: string(WineRedColor)}},
+		Sizes:  []DiscoveredOption{{ID: string(SizeXL)}},
+	}}}
+
+	catalog, err := DefaultCatalog.WithDiscovery(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if model, _ := catalog.Model(HienMidMPad); !model.Discontinued {
+		t.Fatal("the mousepad missing in its page is not discontinued")
+	}
+
+	for _, color := range catalog.Colors() {
+		for _, size := range catalog.Sizes() {
+			if catalog.IsOffered(HienMidMPad, color, size) {
+				t.Fatalf("the discontinued mousepad is offered in color %q and size %q", string(color), string(size))
+			}
+		}
+	}
+
+	if !catalog.IsOffered(HienSoftMPad, WineRedColor, SizeXL) || catalog.IsOffered(HienSoftMPad, BlackColor, SizeXL) {
+		t.Fatal("the discovered offers of the listed mousepads are not used")
+	}
+
+	// The other pages keep the catalog offers
+	if !catalog.IsOffered(ZeroMidMPad, BlackColor, SizeM) {
+		t.Fatal("the mousepads of the pages not discovered lost their offers")
+	}
+
+	// The discontinued state survives the definition json
+	encoded, err := json.Marshal(catalog.Definition())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var definition CatalogDefinition
+	if err := json.Unmarshal(encoded, &definition); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewCatalog(definition)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reloaded.IsOffered(HienMidMPad, WineRedColor, SizeXL) {
+		t.Fatal("the discontinued state is lost in the definition json")
+	}
+}
+
+// The MPadsTypesTotal constant must follow the built-in models
+func TestMPadsTypesTotal(t *testing.T) {
+	if total := len(DefaultCatalog.MPads()); int(MPadsTypesTotal) != total {
+		t.Fatalf("MPadsTypesTotal is %d but the DefaultCatalog has %d models", MPadsTypesTotal, total)
+	}
+}
@@ -0,0 +1,204 @@
+package artisan
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// *********** COMMAND NOTIFIER ***********
+
+// The command is run once per event with the event json (see Event.MarshalJSON) on stdin and these env vars added to the
+// notifier ones: ARTISAN_EVENT_KIND, ARTISAN_EVENT_TITLE, ARTISAN_NAME, ARTISAN_MPAD, ARTISAN_SIR_ID, ARTISAN_SIZE, ARTISAN_SIZE_ID,
+// ARTISAN_COLOR, ARTISAN_COLOR_ID, ARTISAN_PREFIX, ARTISAN_BARCODE, ARTISAN_IN_STOCK, ARTISAN_PRICE, ARTISAN_PREVIOUS_PRICE,
+// ARTISAN_QUANTITY, ARTISAN_CART (the json array of the cart lines of the checkout events), ARTISAN_URL and ARTISAN_ERROR
+
+// The max duration of a command if CommandNotifier.Timeout is not set
+const DefaultCommandTimeout time.Duration = 30 * time.Second
+
+// The max number of stderr bytes kept in a CommandError
+const commandStderrLimit int = 64 * 1024
+
+// Represent a failed run of a CommandNotifier command
+type CommandError struct {
+	// The path of the executable
+	Path string
+	// The event kind the command was run for
+	Kind EventKind
+	// The exit code of the command (-1 if it didn't exit normally, like when it's killed by the timeout)
+	ExitCode int
+	// The stderr of the command (truncated to the first 64KiB)
+	Stderr string
+	// The underlying error
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	message := fmt.Sprintf("Command %s failed for a %s event (exit code %d): %v", e.Path, e.Kind, e.ExitCode, e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		message += ": " + stderr
+	}
+
+	return message
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Represent a Notifier that runs an executable for every event (a sound, a desktop popup, a home automation script, etc..)
+type CommandNotifier struct {
+	// The path of the executable (looked up in PATH if it doesn't contain a separator)
+	Path string
+	// The arguments passed to the executable
+	Args []string
+	// Additional env vars like "KEY=value" (the process env is always inherited)
+	Env []string
+	// The working directory of the command (if empty the current one is used)
+	Dir string
+	// The max duration of a single run (if zero DefaultCommandTimeout is used), the command is killed once it's over
+	Timeout time.Duration
+	// The event kinds the command is run for (if empty it's run for every event)
+	Kinds []EventKind
+}
+
+// Create's a new command notifier running the executable with the given arguments for every event
+func NewCommandNotifier(path string, args ...string) *CommandNotifier {
+	return &CommandNotifier{Path: path, Args: args}
+}
+
+// Run the command for every event of the notifier kinds (sequentially in the events order), the error joins every failed run
+func (notifier *CommandNotifier) Notify(ctx context.Context, events []Event) error {
+	if notifier.Path == "" {
+		return fmt.Errorf("%w: the command notifier has no executable", ErrInvalidArgument)
+	}
+
+	var errs []error
+	for _, event := range events {
+		if len(notifier.Kinds) > 0 && !slices.Contains(notifier.Kinds, event.Kind) {
+			continue
+		}
+
+		if err := notifier.run(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+
+		// Don't start the other commands if the context is done
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run the command for a single event
+func (notifier *CommandNotifier) run(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Failed to encode the %s event: %w", event.Kind, err)
+	}
+
+	timeout := notifier.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stderr := &limitedBuffer{limit: commandStderrLimit}
+
+	cmd := exec.CommandContext(ctx, notifier.Path, notifier.Args...)
+	cmd.Dir = notifier.Dir
+	cmd.Env = append(append(os.Environ(), notifier.Env...), commandEnv(event)...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = stderr
+	// Don't wait forever for the children that inherited stderr once the command is killed
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		exitCode := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
+
+		if ctx.Err() != nil {
+			err = fmt.Errorf("%w: %w", ctx.Err(), err)
+		}
+
+		return &CommandError{Path: notifier.Path, Kind: event.Kind, ExitCode: exitCode, Stderr: stderr.String(), Err: err}
+	}
+
+	return nil
+}
+
+// Return's the env vars describing the event
+func commandEnv(event Event) []string {
+	summary := event.Summary()
+
+	price, previousPrice := "", ""
+	if summary.Price != 0 {
+		price = strconv.FormatInt(int64(summary.Price), 10)
+	}
+
+	if summary.PreviousPrice != 0 {
+		previousPrice = strconv.FormatInt(int64(summary.PreviousPrice), 10)
+	}
+
+	cart := ""
+	if len(summary.Cart) > 0 {
+		if encoded, err := json.Marshal(summary.Cart); err == nil {
+			cart = string(encoded)
+		}
+	}
+
+	return []string{
+		"ARTISAN_EVENT_KIND=" + string(summary.Kind),
+		"ARTISAN_EVENT_TITLE=" + summary.Title(),
+		"ARTISAN_NAME=" + summary.Name,
+		"ARTISAN_MPAD=" + summary.MPad,
+		"ARTISAN_SIR_ID=" + summary.SirID,
+		"ARTISAN_SIZE=" + summary.Size,
+		"ARTISAN_SIZE_ID=" + summary.SizeID,
+		"ARTISAN_COLOR=" + summary.Color,
+		"ARTISAN_COLOR_ID=" + summary.ColorID,
+		"ARTISAN_PREFIX=" + summary.Prefix,
+		"ARTISAN_BARCODE=" + summary.Barcode,
+		"ARTISAN_IN_STOCK=" + strconv.FormatBool(summary.InStock),
+		"ARTISAN_PRICE=" + price,
+		"ARTISAN_PREVIOUS_PRICE=" + previousPrice,
+		"ARTISAN_QUANTITY=" + strconv.Itoa(summary.Quantity),
+		"ARTISAN_CART=" + cart,
+		"ARTISAN_URL=" + summary.Url,
+		"ARTISAN_ERROR=" + summary.Error,
+	}
+}
+
+// Represent a writer that keeps only the first limit bytes written
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		b.buf.Write(p[:min(len(p), remaining)])
+	}
+
+	// Report everything as written so the command is never blocked by a full buffer
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,329 @@
+package artisan
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// *********** CURRENCY CONVERSION ***********
+
+// Represent an ISO 4217 currency code
+type Currency string
+
+// Contains the most used currencies (any other ISO 4217 code can be used as long as the rate table contains it)
+const (
+	JPY Currency = "JPY"
+	EUR Currency = "EUR"
+	USD Currency = "USD"
+	GBP Currency = "GBP"
+	CHF Currency = "CHF"
+)
+
+// Represent a table of exchange rates, every rate is the amount of the currency equal to 1 unit of the base currency
+type RateTable struct {
+	// The base currency of the rates
+	Base Currency
+	// The date the rates refer to
+	Date time.Time
+	// The rates of every currency
+	Rates map[Currency]float64
+}
+
+// Return's the rate to convert 1 unit of the from currency in the to currency
+func (table *RateTable) Rate(from Currency, to Currency) (float64, error) {
+	fromRate, err := table.baseRate(from)
+	if err != nil {
+		return 0, err
+	}
+
+	toRate, err := table.baseRate(to)
+	if err != nil {
+		return 0, err
+	}
+
+	return toRate / fromRate, nil
+}
+
+// Return's the rate of the currency against the base currency
+func (table *RateTable) baseRate(currency Currency) (float64, error) {
+	if currency == table.Base {
+		return 1, nil
+	}
+
+	rate, ok := table.Rates[currency]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, currency)
+	}
+
+	return rate, nil
+}
+
+// Represent a source of exchange rates, implement it to use custom rates (a database, an http api, etc..)
+type RateProvider interface {
+	Rates(ctx context.Context) (*RateTable, error)
+}
+
+// Represent a RateProvider that always return's the same rate table
+type StaticRateProvider struct {
+	Table *RateTable
+}
+
+func (provider *StaticRateProvider) Rates(ctx context.Context) (*RateTable, error) {
+	if provider.Table == nil {
+		return nil, fmt.Errorf("%w: the static rate provider has no table", ErrInvalidArgument)
+	}
+
+	return provider.Table, nil
+}
+
+// Represent a RateProvider that reads the rates from a local file every time (see LoadRatesFile for the supported formats)
+type FileRateProvider struct {
+	Path string
+}
+
+func (provider *FileRateProvider) Rates(ctx context.Context) (*RateTable, error) {
+	return LoadRatesFile(provider.Path)
+}
+
+// Load a rate table from a local file choosing the format by extension: .xml for the ECB daily reference rates,
+// .json for the LoadRatesJSON format and .csv for the LoadRatesCSV format
+func LoadRatesFile(path string) (*RateTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return LoadECBRates(file)
+	case ".json":
+		return LoadRatesJSON(file)
+	case ".csv":
+		return LoadRatesCSV(file)
+	default:
+		return nil, fmt.Errorf("%w: unsupported rates file extension %q", ErrInvalidArgument, filepath.Ext(path))
+	}
+}
+
+// Load a rate table from the ECB daily reference rates xml (https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml), the base is EUR
+func LoadECBRates(r io.Reader) (*RateTable, error) {
+	var envelope struct {
+		Cube struct {
+			Cube []struct {
+				Time string `xml:"time,attr"`
+				Cube []struct {
+					Currency string  `xml:"currency,attr"`
+					Rate     float64 `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("Failed to decode ECB rates: %w", err)
+	}
+
+	if len(envelope.Cube.Cube) == 0 {
+		return nil, errors.New("Failed to decode ECB rates: no rates found")
+	}
+
+	// Take the most recent day (the daily file contains only one, the historical ones start from the most recent)
+	day := envelope.Cube.Cube[0]
+
+	date, err := time.Parse(time.DateOnly, day.Time)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode ECB rates date: %w", err)
+	}
+
+	table := &RateTable{Base: EUR, Date: date, Rates: map[Currency]float64{}}
+	for _, rate := range day.Cube {
+		table.Rates[Currency(rate.Currency)] = rate.Rate
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// Load a rate table from a json like {"base": "EUR", "date": "2024-06-28", "rates": {"JPY": 172.37, "USD": 1.0705}}
+func LoadRatesJSON(r io.Reader) (*RateTable, error) {
+	var file struct {
+		Base  Currency             `json:"base"`
+		Date  string               `json:"date"`
+		Rates map[Currency]float64 `json:"rates"`
+	}
+
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("Failed to decode json rates: %w", err)
+	}
+
+	date, err := time.Parse(time.DateOnly, file.Date)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode json rates date: %w", err)
+	}
+
+	table := &RateTable{Base: file.Base, Date: date, Rates: file.Rates}
+
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// Load a rate table from a csv with a "date,base,currency,rate" header and one row per currency like
+// 2024-06-28,EUR,JPY,172.37 (every row must have the same date and base)
+func LoadRatesCSV(r io.Reader) (*RateTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to decode csv rates: %w", err)
+	}
+
+	if len(records) < 2 {
+		return nil, errors.New("Failed to decode csv rates: no rates found")
+	}
+
+	table := &RateTable{Rates: map[Currency]float64{}}
+
+	// Skip the header
+	for i, record := range records[1:] {
+		date, err := time.Parse(time.DateOnly, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode csv rates date at row %d: %w", i+2, err)
+		}
+
+		rate, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to decode csv rate at row %d: %w", i+2, err)
+		}
+
+		if i == 0 {
+			table.Date, table.Base = date, Currency(record[1])
+		} else if !date.Equal(table.Date) || Currency(record[1]) != table.Base {
+			return nil, fmt.Errorf("Failed to decode csv rates: row %d has a different date or base", i+2)
+		}
+
+		table.Rates[Currency(record[2])] = rate
+	}
+
+	if err := table.validate(); err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+// Check if the rate table is usable
+func (table *RateTable) validate() error {
+	if table.Base == "" {
+		return errors.New("Invalid rate table: missing base currency")
+	}
+
+	for currency, rate := range table.Rates {
+		if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return fmt.Errorf("Invalid rate table: invalid rate %v for %s", rate, currency)
+		}
+	}
+
+	return nil
+}
+
+// Represent a price converted from yen in another currency
+type Price struct {
+	// The amount in the minor units of the currency (cents for EUR/USD, yen for JPY)
+	Amount int64
+	// The currency of the amount
+	Currency Currency
+	// The rate used to convert 1 yen in the currency
+	Rate float64
+	// The date of the rate used
+	RateDate time.Time
+}
+
+// Return's the amount in major units (like 12.34 for 1234 cents)
+func (price Price) Float64() float64 {
+	return float64(price.Amount) / math.Pow10(currencyDecimals(price.Currency))
+}
+
+// Format the price like "12.34 EUR"
+func (price Price) String() string {
+	return strconv.FormatFloat(price.Float64(), 'f', currencyDecimals(price.Currency), 64) + " " + string(price.Currency)
+}
+
+// Represent a converter from yen to other currencies using the rates of a RateProvider
+type Converter struct {
+	Provider RateProvider
+}
+
+// Create's a new converter using the given rate provider
+func NewConverter(provider RateProvider) *Converter {
+	return &Converter{Provider: provider}
+}
+
+// Convert a yen amount in the given currency
+func (converter *Converter) Convert(ctx context.Context, amount Yen, to Currency) (Price, error) {
+	if converter.Provider == nil {
+		return Price{}, fmt.Errorf("%w: the converter has no rate provider", ErrInvalidArgument)
+	}
+
+	table, err := converter.Provider.Rates(ctx)
+	if err != nil {
+		return Price{}, err
+	}
+
+	rate, err := table.Rate(JPY, to)
+	if err != nil {
+		return Price{}, err
+	}
+
+	return Price{
+		Amount:   int64(math.Round(float64(amount) * rate * math.Pow10(currencyDecimals(to)))),
+		Currency: to,
+		Rate:     rate,
+		RateDate: table.Date,
+	}, nil
+}
+
+// Convert the product price in the given currency
+func (converter *Converter) ProductPrice(ctx context.Context, p *Product, to Currency) (Price, error) {
+	if p == nil {
+		return Price{}, fmt.Errorf("%w: product cannot be nil", ErrInvalidArgument)
+	}
+
+	return converter.Convert(ctx, p.PriceYen, to)
+}
+
+// Convert the cart total of the session in the given currency
+func (converter *Converter) CartTotal(ctx context.Context, api *APISession, to Currency) (Price, error) {
+	return converter.Convert(ctx, api.CartTotal(), to)
+}
+
+// Return's the number of decimals of the currency minor unit
+func currencyDecimals(currency Currency) int {
+	switch currency {
+	case JPY, "KRW", "ISK", "CLP", "VND":
+		return 0
+	case "BHD", "KWD", "OMR":
+		return 3
+	default:
+		return 2
+	}
+}
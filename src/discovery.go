@@ -0,0 +1,268 @@
+package artisan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// *********** CATALOG DISCOVERY ***********
+
+// Every product page (the MPadUrls ones) contains a form with three selects (sir, size and color) whose options are the variants
+// sent to get_syouhin.php, discovery fetches those pages and compares the options with the catalog
+
+// Represent a single select option found in a product page
+type DiscoveredOption struct {
+	// The option value (the id used by the website)
+	ID string
+	// The option label
+	Label string
+}
+
+// Represent the variants found in a single product page
+type DiscoveredPage struct {
+	// The url of the page
+	URL string
+	// The mousepads (sir select options)
+	MPads []DiscoveredOption
+	// The sizes (size select options)
+	Sizes []DiscoveredOption
+	// The colors (color select options)
+	Colors []DiscoveredOption
+}
+
+// Represent the result of a catalog discovery compared with the session catalog
+type DiscoveryReport struct {
+	// Every page fetched successfully
+	Pages []DiscoveredPage
+	// The mousepads found in the pages but missing in the catalog
+	NewMPads []DiscoveredOption
+	// The catalog mousepads missing in their page
+	RemovedMPads []MPad
+	// The colors found in the pages but missing in the catalog
+	NewColors []DiscoveredOption
+	// The catalog colors missing in every page (only reported if every page has been fetched)
+	RemovedColors []Color
+	// The sizes found in the pages but missing in the catalog
+	NewSizes []DiscoveredOption
+	// The catalog sizes missing in every page (only reported if every page has been fetched)
+	RemovedSizes []Size
+}
+
+// Return's true if the pages and the catalog differ
+func (report *DiscoveryReport) HasChanges() bool {
+	return len(report.NewMPads) > 0 || len(report.RemovedMPads) > 0 ||
+		len(report.NewColors) > 0 || len(report.RemovedColors) > 0 ||
+		len(report.NewSizes) > 0 || len(report.RemovedSizes) > 0
+}
+
+// Fetch every product page of the session catalog, extract the sir/size/color options and compare them with the catalog.
+// The report is always returned (unless the session is not initialized) with the pages fetched, the error joins every page failure
+func (api *APISession) DiscoverCatalog(ctx context.Context) (*DiscoveryReport, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	// Collect the distinct pages of the catalog
+	var pageUrls []string
+	for _, model := range api.catalog.Models() {
+		if !slices.Contains(pageUrls, model.URL) {
+			pageUrls = append(pageUrls, model.URL)
+		}
+	}
+
+	// Fetch every page (sequentially, they are just a few)
+	var pages []DiscoveredPage
+	var errs []error
+	for _, pageUrl := range pageUrls {
+		page, err := api.DiscoverPage(ctx, pageUrl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to discover %s: %w", pageUrl, err))
+			continue
+		}
+
+		pages = append(pages, *page)
+	}
+
+	report := api.catalog.diff(pages, len(errs) == 0)
+
+	return report, errors.Join(errs...)
+}
+
+// Fetch a single product page (an url of the catalog, it's rebased on the session base url) and extract the sir/size/color options
+func (api *APISession) DiscoverPage(ctx context.Context, pageUrl string) (*DiscoveredPage, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	logger := api.logger.With(slog.String("operation", "DiscoverPage"), slog.String("url", pageUrl))
+
+	// Fetch the page retrying the transient failures
+	var body []byte
+	err := api.retryPolicy.do(ctx, logRetry(ctx, logger), func(attempt int) error {
+		req, err := http.NewRequestWithContext(ctx, "GET", api.resolveURL(pageUrl), nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := api.send(ctx, api.httpClient, req, logger.With(slog.Int("attempt", attempt)))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if err := checkResponseStatus(resp); err != nil {
+			return err
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		return err
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "request failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	page, err := ParseProductPage(body)
+	if err != nil {
+		return nil, err
+	}
+
+	page.URL = pageUrl
+
+	return page, nil
+}
+
+// Contains the regular expressions used to parse the product pages
+var (
+	pageSelectRegexp = regexp.MustCompile(`(?is)<select\b([^>]*)>(.*?)</select>`)
+	pageOptionRegexp = regexp.MustCompile(`(?is)<option\b([^>]*)>([^<]*)`)
+	pageNameRegexp   = regexp.MustCompile(`(?i)(?:^|\s)name\s*=\s*["']?([\w-]+)`)
+	pageIdRegexp     = regexp.MustCompile(`(?i)(?:^|\s)id\s*=\s*["']?([\w-]+)`)
+	pageValueRegexp  = regexp.MustCompile(`(?i)(?:^|\s)value\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+)
+
+// Extract the sir/size/color select options from a product page html, return's a *MalformedResponseError if none of the selects is found
+func ParseProductPage(body []byte) (*DiscoveredPage, error) {
+	page := &DiscoveredPage{}
+
+	for _, selectMatch := range pageSelectRegexp.FindAllSubmatch(body, -1) {
+		// The select is identified by its name attribute, the id is used only if it has no name
+		nameMatch := pageNameRegexp.FindSubmatch(selectMatch[1])
+		if nameMatch == nil {
+			nameMatch = pageIdRegexp.FindSubmatch(selectMatch[1])
+		}
+
+		if nameMatch == nil {
+			continue
+		}
+
+		var target *[]DiscoveredOption
+		switch strings.ToLower(string(nameMatch[1])) {
+		case "sir":
+			target = &page.MPads
+		case "size":
+			target = &page.Sizes
+		case "color":
+			target = &page.Colors
+		default:
+			continue
+		}
+
+		for _, optionMatch := range pageOptionRegexp.FindAllSubmatch(selectMatch[2], -1) {
+			valueMatch := pageValueRegexp.FindSubmatch(optionMatch[1])
+			if valueMatch == nil {
+				continue
+			}
+
+			// Skip the placeholders without a value (like "Please select")
+			id := strings.TrimSpace(string(valueMatch[1]) + string(valueMatch[2]) + string(valueMatch[3]))
+			if id == "" {
+				continue
+			}
+
+			*target = append(*target, DiscoveredOption{
+				ID:    id,
+				Label: strings.TrimSpace(html.UnescapeString(string(optionMatch[2]))),
+			})
+		}
+	}
+
+	if page.MPads == nil && page.Sizes == nil && page.Colors == nil {
+		return nil, &MalformedResponseError{Reason: "no sir/size/color select found in the product page", Body: string(body)}
+	}
+
+	return page, nil
+}
+
+// Compare the discovered pages with the catalog, the removed colors and sizes are computed only if complete is true
+func (catalog *Catalog) diff(pages []DiscoveredPage, complete bool) *DiscoveryReport {
+	report := &DiscoveryReport{Pages: pages}
+
+	seenColors := map[Color]bool{}
+	seenSizes := map[Size]bool{}
+
+	for _, page := range pages {
+		// New mousepads in the page
+		for _, option := range page.MPads {
+			if _, ok := catalog.Model(MPad(option.ID)); !ok && !slices.Contains(report.NewMPads, option) {
+				report.NewMPads = append(report.NewMPads, option)
+			}
+		}
+
+		// Catalog mousepads of the page missing in it (pages without a sir select have a single mousepad, nothing to compare)
+		if len(page.MPads) > 0 {
+			for _, model := range catalog.Models() {
+				found := slices.ContainsFunc(page.MPads, func(option DiscoveredOption) bool { return option.ID == string(model.SirID) })
+				if model.URL == page.URL && !found {
+					report.RemovedMPads = append(report.RemovedMPads, model.SirID)
+				}
+			}
+		}
+
+		// New colors and sizes
+		for _, option := range page.Colors {
+			seenColors[Color(option.ID)] = true
+			if catalog.ColorName(Color(option.ID)) == "" && !slices.Contains(report.NewColors, option) {
+				report.NewColors = append(report.NewColors, option)
+			}
+		}
+
+		for _, option := range page.Sizes {
+			seenSizes[Size(option.ID)] = true
+			if catalog.SizeName(Size(option.ID)) == "" && !slices.Contains(report.NewSizes, option) {
+				report.NewSizes = append(report.NewSizes, option)
+			}
+		}
+	}
+
+	if !complete {
+		return report
+	}
+
+	// Catalog colors and sizes missing in every page
+	for _, color := range catalog.Colors() {
+		if !seenColors[color] {
+			report.RemovedColors = append(report.RemovedColors, color)
+		}
+	}
+
+	for _, size := range catalog.Sizes() {
+		if !seenSizes[size] {
+			report.RemovedSizes = append(report.RemovedSizes, size)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,73 @@
+package artisan
+
+import (
+	"errors"
+	"fmt"
+)
+
+// *********** ERRORS ***********
+
+// Contains all the sentinel errors returned by the apis, use errors.Is to check for them
+var (
+	// Returned when a website response can't be parsed (the returned error is a *MalformedResponseError containing the raw body)
+	ErrMalformedResponse = errors.New("Malformed response")
+	// Returned when a get_syouhin.php response doesn't match the expected schema (the returned error is a *SchemaDriftError
+	// containing the offending payload), it also matches ErrMalformedResponse
+	ErrSchemaDrift = errors.New("Response schema drift")
+	// Returned when the website answers with a non 2xx status code (the returned error is a *HTTPStatusError containing the code)
+	ErrHTTPStatus = errors.New("Unexpected HTTP status")
+	// Returned when trying to use a product that is out of stock (for example adding it to the cart)
+	ErrOutOfStock = errors.New("Product is out of stock")
+	// Returned when the session has not been created with NewAPISession or its cookies are missing
+	ErrSessionNotInitialized = errors.New("Session not initialized")
+	// Returned when trying to checkout without setting a shipping address first
+	ErrAddressNotSet = errors.New("Shipping address not set")
+	// Returned when the shipping address has empty fields
+	ErrInvalidAddress = errors.New("Invalid shipping address")
+	// Returned when a catalog definition is incomplete, duplicated or malformed
+	ErrInvalidCatalog = errors.New("Invalid catalog")
+	// Returned when a mousepad, color or size name can't be parsed (the returned error is a *NameError with the closest names)
+	ErrUnknownName = errors.New("Unknown name")
+	// Returned when a sku can't be decoded or a product combination can't be encoded in a sku
+	ErrInvalidSKU = errors.New("Invalid SKU")
+	// Returned when a product id is not a valid EAN-13 barcode
+	ErrInvalidBarcode = errors.New("Invalid barcode")
+	// Returned when a nil or out of range argument is passed to an api
+	ErrInvalidArgument = errors.New("Invalid argument")
+	// Returned when the rate table doesn't contain the requested currency
+	ErrUnknownCurrency = errors.New("Unknown currency")
+)
+
+// Represent a website response that can't be parsed, it matches ErrMalformedResponse with errors.Is
+type MalformedResponseError struct {
+	// The reason why the response is malformed
+	Reason string
+	// The raw body received
+	Body string
+}
+
+func (e *MalformedResponseError) Error() string {
+	return fmt.Sprintf("%s: %s (body: %q)", ErrMalformedResponse.Error(), e.Reason, e.Body)
+}
+
+func (e *MalformedResponseError) Is(target error) bool {
+	return target == ErrMalformedResponse
+}
+
+// Represent a website response with an unexpected status code, it matches ErrHTTPStatus with errors.Is
+type HTTPStatusError struct {
+	// The status code received
+	StatusCode int
+	// The full status text received (like "503 Service Unavailable")
+	Status string
+	// The url of the request
+	Url string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s: %s (%s)", ErrHTTPStatus.Error(), e.Status, e.Url)
+}
+
+func (e *HTTPStatusError) Is(target error) bool {
+	return target == ErrHTTPStatus
+}
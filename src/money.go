@@ -0,0 +1,82 @@
+package artisan
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// *********** MONEY ***********
+
+// Represent an amount of yen(jpy), the yen has no subunits so the amount is always an integer
+type Yen int64
+
+// Parse a yen amount like "2700.0", "2700", "2,700" or "¥2,700" (decimals are rounded to the nearest yen)
+func ParseYen(s string) (Yen, error) {
+	cleaned := strings.TrimSpace(s)
+	cleaned = strings.TrimPrefix(cleaned, "¥")
+	cleaned = strings.TrimSuffix(cleaned, "JPY")
+	cleaned = strings.ReplaceAll(strings.TrimSpace(cleaned), ",", "")
+
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("%w: invalid yen amount %q", ErrInvalidArgument, s)
+	}
+
+	// float64(math.MaxInt64) is 2^63 so the amount must be strictly lower to fit in an int64
+	amount = math.Round(amount)
+	if amount >= math.MaxInt64 || amount < math.MinInt64 {
+		return 0, fmt.Errorf("%w: yen amount %q out of range", ErrInvalidArgument, s)
+	}
+
+	return Yen(amount), nil
+}
+
+// Return's the sum of the two amounts
+func (y Yen) Add(other Yen) Yen {
+	return y + other
+}
+
+// Return's the difference of the two amounts
+func (y Yen) Sub(other Yen) Yen {
+	return y - other
+}
+
+// Return's the amount multiplied by a quantity
+func (y Yen) Mul(quantity int64) Yen {
+	return y * Yen(quantity)
+}
+
+// Compare two amounts, return's -1 if y < other, 0 if they are equal and +1 if y > other
+func (y Yen) Cmp(other Yen) int {
+	switch {
+	case y < other:
+		return -1
+	case y > other:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Format the amount like "¥2,700"
+func (y Yen) String() string {
+	digits := strconv.FormatInt(int64(y), 10)
+
+	sign := ""
+	if y < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	// Insert the thousands separators
+	var builder strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteRune(digit)
+	}
+
+	return sign + "¥" + builder.String()
+}
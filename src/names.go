@@ -0,0 +1,544 @@
+package artisan
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+)
+
+// *********** NAMES PARSING ***********
+
+// Names are matched case-insensitively ignoring spaces and punctuation ("Wine red", "wine-red" and "WINERED" are the same name), every
+// entry can be found by its site id, its name or one of its aliases. A single typo is tolerated if it matches only one entry (and the name
+// is at least 4 characters long), otherwise a *NameError with the closest names is returned
+
+// Represent a name that can't be resolved to a catalog entry, it matches ErrUnknownName with errors.Is
+type NameError struct {
+	// The kind of entry searched ("mousepad", "color", "size" or "country")
+	Kind string
+	// The input that can't be resolved
+	Input string
+	// The closest names found (if any)
+	Suggestions []string
+}
+
+func (e *NameError) Error() string {
+	message := fmt.Sprintf("%s: unknown %s %q", ErrUnknownName.Error(), e.Kind, e.Input)
+	if len(e.Suggestions) > 0 {
+		message += ", did you mean " + strings.Join(quoteAll(e.Suggestions), " or ") + "?"
+	}
+
+	return message
+}
+
+func (e *NameError) Is(target error) bool {
+	return target == ErrUnknownName
+}
+
+// Contains the aliases generated for every hardness
+var hardnessAliases = map[Hardness][]string{
+	XSoftHardness: {"xsoft", "xs", "extrasoft"},
+	SoftHardness:  {"soft", "s"},
+	MidHardness:   {"mid", "m", "medium"},
+}
+
+// Contains the aliases generated for every series (the series name is always an alias)
+var seriesAliases = map[Series][]string{
+	HayateOtsuSeries: {"otsu"},
+	HayateKouSeries:  {"kou"},
+	Type99Series:     {"99"},
+	ShidenkaiSeries:  {"shidenkaiv2"},
+}
+
+// Return's the display name of the model like "Hien FX MID"
+func (model Model) Name() string {
+	return fmt.Sprintf("%s %s %s", model.Series, model.Line, model.Hardness)
+}
+
+// Represent the lookup tables of the names of one kind of entry
+type nameIndex struct {
+	// The kind of entry
+	kind string
+	// If true the inputs made only of digits are taken as raw site ids (they must be in the catalog)
+	rawIDs bool
+	// The ids of every normalized name or alias
+	ids map[string][]string
+	// The display name of every id
+	displayNames map[string]string
+}
+
+// Add a name (or alias) for the id
+func (index *nameIndex) add(name string, id string) {
+	key := normalizeName(name)
+	if key != "" && !slices.Contains(index.ids[key], id) {
+		index.ids[key] = append(index.ids[key], id)
+	}
+}
+
+// Resolve the input to an id, an input made only of digits is always taken as a raw site id
+func (index *nameIndex) resolve(input string) (string, error) {
+	trimmed := strings.TrimSpace(input)
+	if index.rawIDs && isDigits(trimmed) {
+		if _, ok := index.displayNames[trimmed]; ok {
+			return trimmed, nil
+		}
+
+		// Unknown id, suggest the ids of the same length with a single wrong digit
+		var closeIds []string
+		for id := range index.displayNames {
+			if len(id) == len(trimmed) && editDistance(trimmed, id) <= 1 {
+				closeIds = append(closeIds, id)
+			}
+		}
+
+		slices.Sort(closeIds)
+		return "", &NameError{Kind: index.kind, Input: input, Suggestions: index.display(closeIds)}
+	}
+
+	key := normalizeName(trimmed)
+
+	// Exact match
+	if ids, ok := index.ids[key]; ok {
+		if len(ids) == 1 {
+			return ids[0], nil
+		}
+
+		return "", &NameError{Kind: index.kind, Input: input, Suggestions: index.display(ids)}
+	}
+
+	// Fuzzy match, collect the ids of the closest names
+	bestDistance := -1
+	var bestIds []string
+	var closeIds []string
+	for name, ids := range index.ids {
+		distance := editDistance(key, name)
+		if distance > max(2, len(key)/3) {
+			continue
+		}
+
+		for _, id := range ids {
+			if !slices.Contains(closeIds, id) {
+				closeIds = append(closeIds, id)
+			}
+		}
+
+		if bestDistance == -1 || distance < bestDistance {
+			bestDistance, bestIds = distance, nil
+		}
+
+		if distance == bestDistance {
+			for _, id := range ids {
+				if !slices.Contains(bestIds, id) {
+					bestIds = append(bestIds, id)
+				}
+			}
+		}
+	}
+
+	// A single typo matching a single entry is accepted (only on names long enough to not be confused)
+	if bestDistance == 1 && len(bestIds) == 1 && len(key) >= 4 {
+		return bestIds[0], nil
+	}
+
+	slices.Sort(closeIds)
+	if len(closeIds) > 3 {
+		closeIds = bestIds
+	}
+
+	return "", &NameError{Kind: index.kind, Input: input, Suggestions: index.display(closeIds)}
+}
+
+// Return's the display names of the ids
+func (index *nameIndex) display(ids []string) []string {
+	names := make([]string, 0, len(ids))
+	for _, id := range ids {
+		names = append(names, index.displayNames[id])
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// Build the name indexes of the catalog
+func (catalog *Catalog) buildNameIndexes() {
+	catalog.mpadNames = &nameIndex{kind: "mousepad", rawIDs: true, ids: map[string][]string{}, displayNames: map[string]string{}}
+	for _, model := range catalog.definition.Models {
+		id := string(model.SirID)
+		catalog.mpadNames.displayNames[id] = model.Name()
+
+		series := append([]string{string(model.Series)}, seriesAliases[model.Series]...)
+		hardness := append([]string{string(model.Hardness)}, hardnessAliases[model.Hardness]...)
+
+		for _, seriesName := range series {
+			for _, hardnessName := range hardness {
+				catalog.mpadNames.add(seriesName+string(model.Line)+hardnessName, id)
+
+				// The FX line is the default one, the classic one must be written explicitly
+				if model.Line == ClassicLine {
+					catalog.mpadNames.add(seriesName+"classic"+hardnessName, id)
+					catalog.mpadNames.add("classic"+seriesName+hardnessName, id)
+				} else {
+					catalog.mpadNames.add(seriesName+hardnessName, id)
+				}
+			}
+		}
+
+		for _, alias := range model.Aliases {
+			catalog.mpadNames.add(alias, id)
+		}
+	}
+
+	catalog.colorNameIndex = &nameIndex{kind: "color", rawIDs: true, ids: map[string][]string{}, displayNames: map[string]string{}}
+	for _, color := range catalog.definition.Colors {
+		catalog.colorNameIndex.displayNames[string(color.ID)] = color.Name
+		catalog.colorNameIndex.add(color.Name, string(color.ID))
+		for _, alias := range color.Aliases {
+			catalog.colorNameIndex.add(alias, string(color.ID))
+		}
+	}
+
+	catalog.sizeNameIndex = &nameIndex{kind: "size", rawIDs: true, ids: map[string][]string{}, displayNames: map[string]string{}}
+	for _, size := range catalog.definition.Sizes {
+		catalog.sizeNameIndex.displayNames[string(size.ID)] = size.Name
+		catalog.sizeNameIndex.add(size.Name, string(size.ID))
+		for _, alias := range size.Aliases {
+			catalog.sizeNameIndex.add(alias, string(size.ID))
+		}
+	}
+}
+
+// Parse a mousepad name like "hien mid", "Zero classic xsoft" or "hayate otsu fx soft" (or a raw sir id like "142")
+func (catalog *Catalog) ParseMPad(name string) (MPad, error) {
+	id, err := catalog.mpadNames.resolve(name)
+	return MPad(id), err
+}
+
+// Parse a color name like "wine red" or "black" (or a raw color id like "1")
+func (catalog *Catalog) ParseColor(name string) (Color, error) {
+	id, err := catalog.colorNameIndex.resolve(name)
+	return Color(id), err
+}
+
+// Parse a size name like "XL" or "large" (or a raw size id like "4")
+func (catalog *Catalog) ParseSize(name string) (Size, error) {
+	id, err := catalog.sizeNameIndex.resolve(name)
+	return Size(id), err
+}
+
+// Parse a mousepad name using the DefaultCatalog (see Catalog.ParseMPad)
+func ParseMPad(name string) (MPad, error) {
+	return DefaultCatalog.ParseMPad(name)
+}
+
+// Parse a color name using the DefaultCatalog (see Catalog.ParseColor)
+func ParseColor(name string) (Color, error) {
+	return DefaultCatalog.ParseColor(name)
+}
+
+// Parse a size name using the DefaultCatalog (see Catalog.ParseSize)
+func ParseSize(name string) (Size, error) {
+	return DefaultCatalog.ParseSize(name)
+}
+
+// Parse the mousepad from a name using the DefaultCatalog or from a raw id (it makes MPad usable with json and flag.TextVar)
+func (pad *MPad) UnmarshalText(text []byte) error {
+	// An empty text is the zero value so the zero value round-trips
+	if len(bytes.TrimSpace(text)) == 0 {
+		*pad = ""
+		return nil
+	}
+
+	// A raw id is kept as is even if it's not in the DefaultCatalog, so the ids added by a catalog file round-trip
+	if trimmed := string(bytes.TrimSpace(text)); isDigits(trimmed) {
+		*pad = MPad(trimmed)
+		return nil
+	}
+
+	parsed, err := ParseMPad(string(text))
+	if err != nil {
+		return err
+	}
+
+	*pad = parsed
+	return nil
+}
+
+// Parse the color from a name using the DefaultCatalog or from a raw id (it makes Color usable with json and flag.TextVar)
+func (color *Color) UnmarshalText(text []byte) error {
+	// An empty text is the zero value so the zero value round-trips
+	if len(bytes.TrimSpace(text)) == 0 {
+		*color = ""
+		return nil
+	}
+
+	// A raw id is kept as is even if it's not in the DefaultCatalog, so the ids added by a catalog file round-trip
+	if trimmed := string(bytes.TrimSpace(text)); isDigits(trimmed) {
+		*color = Color(trimmed)
+		return nil
+	}
+
+	parsed, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+
+	*color = parsed
+	return nil
+}
+
+// Parse the size from a name using the DefaultCatalog or from a raw id (it makes Size usable with json and flag.TextVar)
+func (size *Size) UnmarshalText(text []byte) error {
+	// An empty text is the zero value so the zero value round-trips
+	if len(bytes.TrimSpace(text)) == 0 {
+		*size = ""
+		return nil
+	}
+
+	// A raw id is kept as is even if it's not in the DefaultCatalog, so the ids added by a catalog file round-trip
+	if trimmed := string(bytes.TrimSpace(text)); isDigits(trimmed) {
+		*size = Size(trimmed)
+		return nil
+	}
+
+	parsed, err := ParseSize(string(text))
+	if err != nil {
+		return err
+	}
+
+	*size = parsed
+	return nil
+}
+
+// When true MPad, Color and Size are marshaled as raw site ids (like "142") instead of names (like "Hien FX MID")
+var marshalRawIDs atomic.Bool
+
+// Set's if MPad, Color and Size are marshaled as raw site ids instead of names by default (it's safe for concurrent use),
+// use RawMPad, RawColor and RawSize to choose it per field
+func SetMarshalRawIDs(enabled bool) {
+	marshalRawIDs.Store(enabled)
+}
+
+// Return's true if MPad, Color and Size are marshaled as raw site ids by default
+func MarshalRawIDs() bool {
+	return marshalRawIDs.Load()
+}
+
+// Represent a mousepad always marshaled as its raw sir id (like "142"), it's parsed from a name or a raw id like MPad
+type RawMPad MPad
+
+// Represent a color always marshaled as its raw id, it's parsed from a name or a raw id like Color
+type RawColor Color
+
+// Represent a size always marshaled as its raw id, it's parsed from a name or a raw id like Size
+type RawSize Size
+
+// Represent a ProductDetailsBody whose fields are always marshaled as raw ids, get it with ProductDetailsBody.Raw
+type RawProductDetailsBody struct {
+	SirID   RawMPad
+	SizeID  RawSize
+	ColorID RawColor
+}
+
+// Return's a copy of the body with the fields marshaled as raw ids
+func (body ProductDetailsBody) Raw() RawProductDetailsBody {
+	return RawProductDetailsBody{SirID: RawMPad(body.SirID), SizeID: RawSize(body.SizeID), ColorID: RawColor(body.ColorID)}
+}
+
+// Return's the body with the standard types
+func (body RawProductDetailsBody) Body() ProductDetailsBody {
+	return ProductDetailsBody{SirID: MPad(body.SirID), SizeID: Size(body.SizeID), ColorID: Color(body.ColorID)}
+}
+
+func (pad RawMPad) MarshalText() ([]byte, error) {
+	return []byte(pad), nil
+}
+
+func (pad *RawMPad) UnmarshalText(text []byte) error {
+	return (*MPad)(pad).UnmarshalText(text)
+}
+
+func (color RawColor) MarshalText() ([]byte, error) {
+	return []byte(color), nil
+}
+
+func (color *RawColor) UnmarshalText(text []byte) error {
+	return (*Color)(color).UnmarshalText(text)
+}
+
+func (size RawSize) MarshalText() ([]byte, error) {
+	return []byte(size), nil
+}
+
+func (size *RawSize) UnmarshalText(text []byte) error {
+	return (*Size)(size).UnmarshalText(text)
+}
+
+// Return's the display name of the mousepad like "Hien FX MID" (or the raw sir id if it's not in the DefaultCatalog)
+func (pad MPad) String() string {
+	if model, ok := DefaultCatalog.Model(pad); ok {
+		return model.Name()
+	}
+
+	return string(pad)
+}
+
+// Return's the name of the color like "WineRed" (or the raw id if it's not in the DefaultCatalog)
+func (color Color) String() string {
+	if name := DefaultCatalog.ColorName(color); name != "" {
+		return name
+	}
+
+	return string(color)
+}
+
+// Return's the name of the size like "XL" (or the raw id if it's not in the DefaultCatalog)
+func (size Size) String() string {
+	if name := DefaultCatalog.SizeName(size); name != "" {
+		return name
+	}
+
+	return string(size)
+}
+
+// Marshal the mousepad as its name (or as its raw sir id if it's not in the DefaultCatalog or SetMarshalRawIDs(true) has been called)
+func (pad MPad) MarshalText() ([]byte, error) {
+	if marshalRawIDs.Load() {
+		return []byte(pad), nil
+	}
+
+	return []byte(pad.String()), nil
+}
+
+// Marshal the color as its name (or as its raw id if it's not in the DefaultCatalog or SetMarshalRawIDs(true) has been called)
+func (color Color) MarshalText() ([]byte, error) {
+	if marshalRawIDs.Load() {
+		return []byte(color), nil
+	}
+
+	return []byte(color.String()), nil
+}
+
+// Marshal the size as its name (or as its raw id if it's not in the DefaultCatalog or SetMarshalRawIDs(true) has been called)
+func (size Size) MarshalText() ([]byte, error) {
+	if marshalRawIDs.Load() {
+		return []byte(size), nil
+	}
+
+	return []byte(size.String()), nil
+}
+
+// Contains the aliases of the countries
+var countryAliases = map[Country][]string{
+	UnitedStates:       {"usa", "us", "america"},
+	UnitedKingdom:      {"uk", "great britain"},
+	CzechRepublic:      {"czechia"},
+	Korea:              {"south korea"},
+	UnitedArabEmirates: {"uae"},
+}
+
+// The name lookup table of the countries
+var countryNameIndex = buildCountryNameIndex()
+
+// Build the name lookup table of the countries
+func buildCountryNameIndex() *nameIndex {
+	index := &nameIndex{kind: "country", ids: map[string][]string{}, displayNames: map[string]string{}}
+	for _, country := range Countries {
+		index.displayNames[string(country)] = string(country)
+		index.add(string(country), string(country))
+		for _, alias := range countryAliases[country] {
+			index.add(alias, string(country))
+		}
+	}
+
+	return index
+}
+
+// Parse a country name like "united states", "UnitedStates" or "USA"
+func ParseCountry(name string) (Country, error) {
+	id, err := countryNameIndex.resolve(name)
+	return Country(id), err
+}
+
+// Return's the country name
+func (country Country) String() string {
+	return string(country)
+}
+
+// Marshal the country as its name
+func (country Country) MarshalText() ([]byte, error) {
+	return []byte(country), nil
+}
+
+// Parse the country from a name (it makes Country usable with json and flag.TextVar)
+func (country *Country) UnmarshalText(text []byte) error {
+	// An empty text is the zero value so the zero value round-trips
+	if len(bytes.TrimSpace(text)) == 0 {
+		*country = ""
+		return nil
+	}
+
+	parsed, err := ParseCountry(string(text))
+	if err != nil {
+		return err
+	}
+
+	*country = parsed
+	return nil
+}
+
+// Normalize a name for the comparisons: lowercase letters and digits only
+func normalizeName(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// Compute the edit distance between two strings (insertions, deletions, substitutions and adjacent transpositions)
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Keep the last two rows of the distance matrix
+	prevPrev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prevPrev[j-2]+1)
+			}
+		}
+
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+
+	return prev[len(rb)]
+}
+
+// Quote every string
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+
+	return quoted
+}
@@ -0,0 +1,181 @@
+package artisan
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// *********** RESPONSE PARSER ***********
+
+// The get_syouhin.php response is a single line of fields separated by '/' like
+// 4562332172443/FX-HI-XS-S-R/HIEN FX XSOFT S Wine red/2700.0/???/XSOFT
+// Id (the EAN-13 barcode or NON if out of stock), Prefix, Name, Price, Unknown, Hardness
+
+// The number of fields of a get_syouhin.php response
+const syouhinFieldsCount int = 6
+
+// Represent a parsed get_syouhin.php response
+type SyouhinResponse struct {
+	// The product id, an EAN-13 barcode (or "NON" if the product is out of stock)
+	Id string
+	// The product prefix/sku
+	Prefix string
+	// The product full name (it can contain '/')
+	Name string
+	// The product price in yen(jpy) as sent by the website
+	Price string
+	// The fifth field, it's meaning is unknown and it's not used
+	Field4 string
+	// The hardness of the product (it's not validated against Hardnesses, see HasKnownHardness)
+	Hardness string
+	// The unknown fields found after the hardness (if the website adds new fields they end up here)
+	Extras []string
+	// All the raw fields of the response
+	Fields []string
+}
+
+// Represent a response that doesn't match the expected get_syouhin.php schema, it matches both ErrSchemaDrift
+// and ErrMalformedResponse with errors.Is
+type SchemaDriftError struct {
+	// The reason why the response doesn't match the schema
+	Reason string
+	// The offending payload
+	Payload string
+}
+
+func (e *SchemaDriftError) Error() string {
+	return fmt.Sprintf("%s: %s (payload: %q)", ErrSchemaDrift.Error(), e.Reason, e.Payload)
+}
+
+func (e *SchemaDriftError) Is(target error) bool {
+	return target == ErrSchemaDrift || target == ErrMalformedResponse
+}
+
+// Parse a get_syouhin.php response validating the fields count and types. The name is allowed to contain '/' and the unknown trailing fields
+// are kept in Extras as long as the price/hardness position is unambiguous, any other mismatch is reported as a *SchemaDriftError
+// (an empty body is reported as a *MalformedResponseError). An unknown hardness is not a mismatch, it's up to the caller to report it
+// (see HasKnownHardness)
+func ParseSyouhinResponse(body []byte) (*SyouhinResponse, error) {
+
+	payload := strings.TrimSpace(string(body))
+	if payload == "" {
+		return nil, &MalformedResponseError{Reason: "empty response", Body: string(body)}
+	}
+
+	fields := strings.Split(payload, "/")
+	if len(fields) < syouhinFieldsCount {
+		return nil, &SchemaDriftError{
+			Reason:  fmt.Sprintf("expected at least %d fields separated by '/', got %d", syouhinFieldsCount, len(fields)),
+			Payload: payload,
+		}
+	}
+
+	// Validate the id (the barcode check digit too)
+	id := fields[0]
+	outOfStock := id == "NON"
+	if !outOfStock {
+		if err := ValidateEAN13(id); err != nil {
+			return nil, &SchemaDriftError{Reason: fmt.Sprintf("invalid product id: %s", err), Payload: payload}
+		}
+	}
+
+	// Search the price position, with exactly 6 fields it's always the fourth one. With more fields the name can contain '/'
+	// or the website added fields after the hardness, so every position with a valid price followed (two fields later) by a
+	// known hardness is a candidate (or by any hardness if none is known), the payload is parsed only if there's exactly one candidate
+	priceIndex := 3
+	if len(fields) > syouhinFieldsCount {
+		var candidates, unknownCandidates []int
+		for i := 3; i <= len(fields)-3; i++ {
+			if !isSyouhinPrice(fields[i], outOfStock) || fields[i+2] == "" {
+				continue
+			}
+
+			if isKnownHardness(fields[i+2]) {
+				candidates = append(candidates, i)
+			} else {
+				unknownCandidates = append(unknownCandidates, i)
+			}
+		}
+
+		if len(candidates) == 0 {
+			candidates = unknownCandidates
+		}
+
+		if len(candidates) != 1 {
+			return nil, &SchemaDriftError{
+				Reason:  fmt.Sprintf("ambiguous fields, %d possible price/hardness positions found", len(candidates)),
+				Payload: payload,
+			}
+		}
+
+		priceIndex = candidates[0]
+	}
+
+	// Validate the price (out of stock products are allowed to have an empty price)
+	price := fields[priceIndex]
+	if !isSyouhinPrice(price, outOfStock) {
+		return nil, &SchemaDriftError{Reason: fmt.Sprintf("invalid price %q", price), Payload: payload}
+	}
+
+	// Validate the text fields
+	name := strings.Join(fields[2:priceIndex], "/")
+	hardness := fields[priceIndex+2]
+	if fields[1] == "" || strings.TrimSpace(name) == "" || hardness == "" {
+		return nil, &SchemaDriftError{Reason: "empty prefix, name or hardness", Payload: payload}
+	}
+
+	return &SyouhinResponse{
+		Id:       id,
+		Prefix:   fields[1],
+		Name:     name,
+		Price:    price,
+		Field4:   fields[priceIndex+1],
+		Hardness: hardness,
+		Extras:   fields[priceIndex+3:],
+		Fields:   fields,
+	}, nil
+}
+
+// Return's true if the hardness is one of the known values (Hardnesses)
+func (response *SyouhinResponse) HasKnownHardness() bool {
+	return isKnownHardness(response.Hardness)
+}
+
+// Return's true if the string is made only of ascii digits (and is not empty)
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Return's true if the field is a valid price for the response (out of stock products are allowed to have an empty price)
+func isSyouhinPrice(s string, outOfStock bool) bool {
+	return isPrice(s) || (outOfStock && s == "")
+}
+
+// Return's true if the field is one of the known hardness values
+func isKnownHardness(s string) bool {
+	return slices.Contains(Hardnesses, Hardness(s))
+}
+
+// Return's true if the string is a valid non negative price like "2700.0"
+func isPrice(s string) bool {
+	// Only plain decimal numbers are accepted (ParseFloat also accepts "Inf", "0x1p-2", "1_000", etc..)
+	if s == "" || strings.Trim(s, "0123456789.") != "" {
+		return false
+	}
+
+	price, err := strconv.ParseFloat(s, 64)
+	return err == nil && price >= 0 && !math.IsInf(price, 0)
+}
@@ -0,0 +1,47 @@
+package artisan
+
+import (
+	"errors"
+	"testing"
+)
+
+// Fuzz the get_syouhin.php parser, it must never panic and must return either a response or an ErrMalformedResponse error
+func FuzzParseSyouhinResponse(f *testing.F) {
+	seeds := []string{
+		"4562332172443/FX-HI-XS-S-R/HIEN FX XSOFT S Wine red/2700.0/???/XSOFT",
+		"NON/FX-HI-XS-S-R/HIEN FX XSOFT S Wine red//???/XSOFT",
+		"NON/FX-HI-XS-S-R/HIEN FX XSOFT S Wine red//0/XSOFT/extra",
+		"4562332172443/FX-99-M-S-R/TYPE 99 1/2/2700.0/0/MID",
+		"4562332172443/FX-HI-M-S-R/HIEN FX MID S Wine red/2700.0/0/Medium",
+		"NON/FX-HI-M-S-R/HIEN/FX//0/Medium/extra",
+		"NON/////",
+		"",
+	}
+
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		response, err := ParseSyouhinResponse(body)
+		if err != nil {
+			if response != nil {
+				t.Fatalf("got both a response and an error for %q", body)
+			}
+
+			if !errors.Is(err, ErrMalformedResponse) {
+				t.Fatalf("error for %q doesn't match ErrMalformedResponse: %v", body, err)
+			}
+
+			return
+		}
+
+		if response == nil {
+			t.Fatalf("got neither a response nor an error for %q", body)
+		}
+
+		if response.Prefix == "" || response.Name == "" || response.Hardness == "" {
+			t.Fatalf("invalid response for %q: %+v", body, response)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package artisan
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// *********** RATE LIMITER ***********
+
+// Represent a token bucket rate limiter, it can be set on one or more sessions (thru APISessionOptions) to share the same requests budget.
+// It is safe for concurrent use and a nil *RateLimiter never limits.
+type RateLimiter struct {
+	// Protects every field below
+	mu sync.Mutex
+	// The number of tokens added to the bucket every second
+	requestsPerSecond float64
+	// The maximum number of tokens the bucket can hold
+	burst int
+	// The tokens currently available (it goes negative when the waiting requests reserved future tokens)
+	tokens float64
+	// The last time the tokens have been refilled
+	lastRefill time.Time
+}
+
+// Create's a new RateLimiter allowing requestsPerSecond requests on average and bursts of at most burst requests
+// (if requestsPerSecond is <= 0 the limiter never limits, if burst is < 1 it's set to 1)
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		requestsPerSecond: requestsPerSecond,
+		burst:             burst,
+		tokens:            float64(burst),
+		lastRefill:        time.Now(),
+	}
+}
+
+// Waits until a request is allowed or the context is done (in that case the context error is returned and the token given back)
+func (limiter *RateLimiter) Wait(ctx context.Context) error {
+	if limiter == nil || limiter.requestsPerSecond <= 0 {
+		return ctx.Err()
+	}
+
+	// Reserve a token and compute how long to wait for it
+	limiter.mu.Lock()
+
+	now := time.Now()
+	limiter.tokens += now.Sub(limiter.lastRefill).Seconds() * limiter.requestsPerSecond
+	if limiter.tokens > float64(limiter.burst) {
+		limiter.tokens = float64(limiter.burst)
+	}
+	limiter.lastRefill = now
+	limiter.tokens--
+
+	delay := time.Duration(0)
+	if limiter.tokens < 0 {
+		delay = time.Duration(-limiter.tokens / limiter.requestsPerSecond * float64(time.Second))
+	}
+
+	limiter.mu.Unlock()
+
+	if delay == 0 {
+		return ctx.Err()
+	}
+
+	// Waits for the token to be available
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the reserved token so the other requests don't wait for it
+		limiter.mu.Lock()
+		limiter.tokens++
+		limiter.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
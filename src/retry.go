@@ -0,0 +1,152 @@
+package artisan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// *********** RETRY ***********
+
+// Represent the policy used to retry the requests that failed for transient reasons (empty bodies, 5xx, connection resets, etc..)
+type RetryPolicy struct {
+	// The maximum number of attempts including the first one (if <= 1 requests are never retried)
+	MaxAttempts int
+	// The backoff waited before the first retry, it doubles at every retry
+	BaseBackoff time.Duration
+	// The maximum backoff waited between two attempts (if <= 0 the backoff is not capped)
+	MaxBackoff time.Duration
+	// The fraction (0 to 1) of every backoff that is randomized, so that multiple clients don't retry all at the same time
+	Jitter float64
+	// An optional classifier that tells if an error is worth a retry (defaults to IsRetryable)
+	Retryable func(error) bool
+}
+
+// The default retry policy, 3 attempts with an exponential backoff between 500ms and 5s
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  5 * time.Second,
+	Jitter:      0.2,
+}
+
+// Represent the error returned by a request that has been attempted more than once following a RetryPolicy, it wraps the error of the last attempt
+type RetryError struct {
+	// The number of attempts done
+	Attempts int
+	// The error of the last attempt
+	Err error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s (after %d attempts)", e.Err.Error(), e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// The default retry classifier, it tells if an error is transient: network timeouts, reset or refused connections, truncated
+// responses, 5xx and 429 status codes and empty responses are retryable while context errors and every other error (like tls
+// verification errors, unsupported schemes or unknown hosts) are not
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var malformedErr *MalformedResponseError
+	if errors.As(err, &malformedErr) {
+		return malformedErr.Body == ""
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// Calls attemptFn until it succeeds, the error is not retryable, the attempts are over or the context is done.
+// onRetry (if not nil) is called before waiting the backoff of every retry. A nil policy means a single attempt.
+// The error is wrapped in a *RetryError only if more than one attempt has been done.
+func (policy *RetryPolicy) do(ctx context.Context, onRetry func(attempt int, err error, backoff time.Duration), attemptFn func(attempt int) error) error {
+
+	maxAttempts := 1
+	retryable := IsRetryable
+	if policy != nil {
+		maxAttempts = max(policy.MaxAttempts, 1)
+		if policy.Retryable != nil {
+			retryable = policy.Retryable
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := attemptFn(attempt)
+		if err == nil {
+			return nil
+		}
+
+		// Stop if there are no more attempts available or the error is permanent
+		if attempt >= maxAttempts || ctx.Err() != nil || !retryable(err) {
+			return retryError(attempt, err)
+		}
+
+		backoff := policy.backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, backoff)
+		}
+
+		// Waits the backoff before the next attempt
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return retryError(attempt, err)
+		}
+	}
+}
+
+// Wrap the error of the last attempt in a *RetryError if it has been retried
+func retryError(attempts int, err error) error {
+	if attempts <= 1 {
+		return err
+	}
+
+	return &RetryError{Attempts: attempts, Err: err}
+}
+
+// Compute the backoff to wait after the given failed attempt
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+	// The doubling stops before overflowing even if the backoff is not capped
+	backoff := policy.BaseBackoff
+	for i := 1; i < attempt && (policy.MaxBackoff <= 0 || backoff < policy.MaxBackoff) && backoff <= math.MaxInt64/2; i++ {
+		backoff *= 2
+	}
+
+	if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+
+	// Randomize the jitter fraction of the backoff
+	jitter := min(max(policy.Jitter, 0), 1)
+	if jitter > 0 && backoff > 0 {
+		backoff = time.Duration(float64(backoff) * (1 - jitter + rand.Float64()*jitter))
+	}
+
+	return backoff
+}
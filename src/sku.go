@@ -0,0 +1,182 @@
+package artisan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// *********** SKU CODEC ***********
+
+// The product prefix (sku) is made of line, series, hardness, size and color codes separated by '-', for example FX-HI-XS-S-R is
+// the Hien FX XSoft S Wine red. The first three codes identify the model, the other two the size and the color.
+// NOTE: only the codes of FX-HI-XS-S-R come from a real cart cookie, the others follow the same scheme and can be fixed
+// without recompiling with a catalog file (Model.SKU, SizeDefinition.SKUCode and ColorDefinition.SKUCode)
+
+// Contains the sku codes of every series
+var seriesSKUCodes = map[Series]string{
+	ZeroSeries:       "ZR",
+	RaidenSeries:     "RA",
+	HayateOtsuSeries: "HO",
+	HayateKouSeries:  "HK",
+	HienSeries:       "HI",
+	Type99Series:     "99",
+	ShidenkaiSeries:  "SH",
+}
+
+// Contains the sku codes of every hardness
+var hardnessSKUCodes = map[Hardness]string{
+	XSoftHardness: "XS",
+	SoftHardness:  "S",
+	MidHardness:   "M",
+}
+
+// Return's the sku prefix of the model like "FX-HI-XS" (Model.SKU if set, otherwise it's built from the line, series and hardness codes)
+func (model Model) SKUPrefix() string {
+	if model.SKU != "" {
+		return strings.ToUpper(model.SKU)
+	}
+
+	seriesCode, ok := seriesSKUCodes[model.Series]
+	if !ok {
+		seriesCode = strings.ToUpper(string(model.Series))
+	}
+
+	hardnessCode, ok := hardnessSKUCodes[model.Hardness]
+	if !ok {
+		hardnessCode = strings.ToUpper(string(model.Hardness))
+	}
+
+	return strings.ToUpper(string(model.Line)) + "-" + seriesCode + "-" + hardnessCode
+}
+
+// Build the sku lookup tables of the catalog, return's an ErrInvalidCatalog error if two entries have the same code
+func (catalog *Catalog) buildSKUIndexes() error {
+	catalog.skuModels = map[string]MPad{}
+	for _, model := range catalog.definition.Models {
+		code := model.SKUPrefix()
+		if other, ok := catalog.skuModels[code]; ok {
+			return fmt.Errorf("%w: models %q and %q have the same sku %q", ErrInvalidCatalog, string(other), string(model.SirID), code)
+		}
+
+		catalog.skuModels[code] = model.SirID
+	}
+
+	catalog.skuColors = map[string]Color{}
+	for _, color := range catalog.definition.Colors {
+		if color.SKUCode == "" {
+			continue
+		}
+
+		code := strings.ToUpper(color.SKUCode)
+		if other, ok := catalog.skuColors[code]; ok {
+			return fmt.Errorf("%w: colors %q and %q have the same sku code %q", ErrInvalidCatalog, string(other), string(color.ID), code)
+		}
+
+		catalog.skuColors[code] = color.ID
+	}
+
+	catalog.skuSizes = map[string]Size{}
+	for _, size := range catalog.definition.Sizes {
+		code := strings.ToUpper(size.SKUCode)
+		if code == "" {
+			code = strings.ToUpper(size.Name)
+		}
+
+		if other, ok := catalog.skuSizes[code]; ok {
+			return fmt.Errorf("%w: sizes %q and %q have the same sku code %q", ErrInvalidCatalog, string(other), string(size.ID), code)
+		}
+
+		catalog.skuSizes[code] = size.ID
+	}
+
+	return nil
+}
+
+// Build the sku of the product combination like "FX-HI-XS-S-R", return's an ErrInvalidSKU error if an entry is missing or has no code
+func (catalog *Catalog) EncodeSKU(body ProductDetailsBody) (string, error) {
+	model, ok := catalog.Model(body.SirID)
+	if !ok {
+		return "", fmt.Errorf("%w: mousepad %q is not in the catalog", ErrInvalidSKU, string(body.SirID))
+	}
+
+	sizeCode := ""
+	for code, size := range catalog.skuSizes {
+		if size == body.SizeID {
+			sizeCode = code
+		}
+	}
+
+	colorCode := ""
+	for code, color := range catalog.skuColors {
+		if color == body.ColorID {
+			colorCode = code
+		}
+	}
+
+	if sizeCode == "" || colorCode == "" {
+		return "", fmt.Errorf("%w: size %q or color %q has no sku code", ErrInvalidSKU, string(body.SizeID), string(body.ColorID))
+	}
+
+	return model.SKUPrefix() + "-" + sizeCode + "-" + colorCode, nil
+}
+
+// Decode a sku like "FX-HI-XS-S-R" (case insensitive) in the product combination, everything after the first space is ignored
+// so the prefixes of the cart cookies like "FX-HI-XS-S-R HIEN FX XSOFT S Wine red" can be decoded too
+func (catalog *Catalog) DecodeSKU(sku string) (ProductDetailsBody, error) {
+	code := strings.ToUpper(strings.TrimSpace(sku))
+	if i := strings.IndexFunc(code, func(r rune) bool { return r == ' ' || r == '\t' }); i != -1 {
+		code = code[:i]
+	}
+
+	parts := strings.Split(code, "-")
+	if len(parts) < 3 {
+		return ProductDetailsBody{}, fmt.Errorf("%w: %q must be made of model, size and color codes", ErrInvalidSKU, sku)
+	}
+
+	modelCode := strings.Join(parts[:len(parts)-2], "-")
+	sizeCode, colorCode := parts[len(parts)-2], parts[len(parts)-1]
+
+	pad, ok := catalog.skuModels[modelCode]
+	if !ok {
+		return ProductDetailsBody{}, fmt.Errorf("%w: unknown model code %q in %q", ErrInvalidSKU, modelCode, sku)
+	}
+
+	size, ok := catalog.skuSizes[sizeCode]
+	if !ok {
+		return ProductDetailsBody{}, fmt.Errorf("%w: unknown size code %q in %q", ErrInvalidSKU, sizeCode, sku)
+	}
+
+	color, ok := catalog.skuColors[colorCode]
+	if !ok {
+		return ProductDetailsBody{}, fmt.Errorf("%w: unknown color code %q in %q", ErrInvalidSKU, colorCode, sku)
+	}
+
+	return ProductDetailsBody{SirID: pad, SizeID: size, ColorID: color}, nil
+}
+
+// Build the sku of the product combination using the DefaultCatalog (see Catalog.EncodeSKU)
+func EncodeSKU(body ProductDetailsBody) (string, error) {
+	return DefaultCatalog.EncodeSKU(body)
+}
+
+// Decode a sku using the DefaultCatalog (see Catalog.DecodeSKU)
+func DecodeSKU(sku string) (ProductDetailsBody, error) {
+	return DefaultCatalog.DecodeSKU(sku)
+}
+
+// Fetch details about the product with the given sku (decoded with the session catalog)
+func (api *APISession) ProductBySKU(ctx context.Context, sku string) (*Product, error) {
+
+	// Check if the session is usable
+	if err := api.checkInitialized(); err != nil {
+		return nil, err
+	}
+
+	body, err := api.catalog.DecodeSKU(sku)
+	if err != nil {
+		return nil, err
+	}
+
+	return api.ProductDetailsCtx(ctx, body)
+}
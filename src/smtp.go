@@ -0,0 +1,325 @@
+package artisan
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// *********** SMTP NOTIFIER ***********
+
+// Represent how the connection to the smtp server is secured
+type SMTPSecurity string
+
+// Contains all the supported smtp securities
+const (
+	// The connection is upgraded with STARTTLS, the delivery fails if the server doesn't support it
+	SMTPStartTLS SMTPSecurity = "starttls"
+	// The connection is never encrypted (net/smtp refuses to send the credentials on it unless the server is on localhost)
+	SMTPPlain SMTPSecurity = "plain"
+)
+
+// The event kinds delivered if SMTPNotifier.Kinds is empty (every stock state or price change)
+var DefaultSMTPKinds = []EventKind{RestockedEvent, SoldOutEvent, PriceChangedEvent}
+
+// Contains the data passed to the SMTPNotifier templates
+type EmailData struct {
+	// The subject of the email
+	Subject string
+	// When the email has been built
+	Time time.Time
+	// The changed products
+	Events []EventSummary
+}
+
+// The default template of the plain text body
+var DefaultEmailTextTemplate = texttemplate.Must(texttemplate.New("text").Parse(`{{len .Events}} Artisan product(s) changed:
+{{range .Events}}
+- {{.Title}}
+  Color: {{.Color}} | Size: {{.Size}} | Price: {{.PriceText}}
+{{- if .Url}}
+  {{.Url}}
+{{- end}}
+{{end}}`))
+
+// The default template of the html body
+var DefaultEmailHTMLTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<html><body>
+<p>{{len .Events}} Artisan product(s) changed:</p>
+<ul>
+{{- range .Events}}
+<li>{{if .Url}}<a href="{{.Url}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}<br>Color: {{.Color}} | Size: {{.Size}} | Price: {{.PriceText}}</li>
+{{- end}}
+</ul>
+</body></html>
+`))
+
+// Represent a Notifier that sends an email listing the changed products to the recipients
+type SMTPNotifier struct {
+	// The smtp server host
+	Host string
+	// The smtp server port (if zero 587 is used)
+	Port int
+	// How the connection is secured (if empty SMTPStartTLS is used)
+	Security SMTPSecurity
+	// An optional tls config used for STARTTLS (if nil the server name is verified against Host)
+	TLSConfig *tls.Config
+	// The username of the PLAIN auth (if empty the client doesn't authenticate)
+	Username string
+	// The password of the PLAIN auth
+	Password string
+	// The sender address
+	From string
+	// The recipients addresses
+	To []string
+	// The subject of the emails (if empty a subject like "Artisan: 2 product(s) changed" is used)
+	Subject string
+	// The event kinds delivered (if empty DefaultSMTPKinds is used)
+	Kinds []EventKind
+	// The template of the plain text body (if nil DefaultEmailTextTemplate is used)
+	TextTemplate *texttemplate.Template
+	// The template of the html body (if nil DefaultEmailHTMLTemplate is used)
+	HTMLTemplate *htmltemplate.Template
+	// The max duration of a delivery (if zero 30s is used)
+	Timeout time.Duration
+}
+
+// Send a single email with the events of the notifier kinds to every recipient
+func (notifier *SMTPNotifier) Notify(ctx context.Context, events []Event) error {
+	kinds := notifier.Kinds
+	if len(kinds) == 0 {
+		kinds = DefaultSMTPKinds
+	}
+
+	data := EmailData{Subject: notifier.Subject, Time: time.Now()}
+	for _, event := range events {
+		if slices.Contains(kinds, event.Kind) {
+			data.Events = append(data.Events, event.Summary())
+		}
+	}
+
+	if len(data.Events) == 0 {
+		return nil
+	}
+
+	if notifier.Host == "" || notifier.From == "" || len(notifier.To) == 0 {
+		return fmt.Errorf("%w: the smtp notifier needs a host, a sender and at least a recipient", ErrInvalidArgument)
+	}
+
+	if data.Subject == "" {
+		data.Subject = fmt.Sprintf("Artisan: %d product(s) changed", len(data.Events))
+	}
+
+	message, err := notifier.message(data)
+	if err != nil {
+		return fmt.Errorf("Failed to build the email: %w", err)
+	}
+
+	if err := notifier.send(ctx, message); err != nil {
+		return fmt.Errorf("Failed to send the email: %w", err)
+	}
+
+	return nil
+}
+
+// Build the email with a multipart/alternative body made of the text and the html templates
+func (notifier *SMTPNotifier) message(data EmailData) ([]byte, error) {
+	textTemplate := notifier.TextTemplate
+	if textTemplate == nil {
+		textTemplate = DefaultEmailTextTemplate
+	}
+
+	htmlTemplate := notifier.HTMLTemplate
+	if htmlTemplate == nil {
+		htmlTemplate = DefaultEmailHTMLTemplate
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write(part.content); err != nil {
+			return nil, err
+		}
+
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", notifier.From},
+		{"To", strings.Join(notifier.To, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", data.Subject)},
+		{"Date", data.Time.Format(time.RFC1123Z)},
+		{"Message-ID", messageID(notifier.From)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + parts.Boundary()},
+	}
+
+	for _, header := range headers {
+		if strings.ContainsAny(header[1], "\r\n") {
+			return nil, fmt.Errorf("%w: the %s header contains a newline", ErrInvalidArgument, header[0])
+		}
+
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+
+	message.WriteString("\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+// Send the message to every recipient with a single smtp transaction
+func (notifier *SMTPNotifier) send(ctx context.Context, message []byte) error {
+	timeout := notifier.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	port := notifier.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(notifier.Host, strconv.Itoa(port)))
+	if err != nil {
+		return err
+	}
+
+	// net/smtp has no context support, the deadline bounds the whole conversation
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, notifier.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	switch notifier.Security {
+	case SMTPStartTLS, "":
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%w: the server %s doesn't support STARTTLS", ErrInvalidArgument, notifier.Host)
+		}
+
+		tlsConfig := notifier.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: notifier.Host}
+		}
+
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	case SMTPPlain:
+	default:
+		return fmt.Errorf("%w: unknown smtp security %q", ErrInvalidArgument, notifier.Security)
+	}
+
+	if notifier.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", notifier.Username, notifier.Password, notifier.Host)); err != nil {
+			return err
+		}
+	}
+
+	from, err := mail.ParseAddress(notifier.From)
+	if err != nil {
+		return fmt.Errorf("%w: invalid sender %q: %w", ErrInvalidArgument, notifier.From, err)
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+
+	for _, to := range notifier.To {
+		recipient, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("%w: invalid recipient %q: %w", ErrInvalidArgument, to, err)
+		}
+
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(message); err != nil {
+		writer.Close()
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// Return's a random message id on the domain of the sender address
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i != -1 {
+		domain = strings.Trim(from[i+1:], "> ")
+	}
+
+	random := make([]byte, 16)
+	rand.Read(random)
+
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
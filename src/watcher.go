@@ -0,0 +1,334 @@
+package artisan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// *********** STOCK WATCHER ***********
+
+// The watcher polls a set of products on a schedule and emits an event every time one of them changes state,
+// the first poll is the baseline so no event is emitted for it (apart from the failures)
+
+// The interval used if WatcherOptions.Interval is not set
+const DefaultWatchInterval time.Duration = 5 * time.Minute
+
+// Represent the kind of a watcher event
+type EventKind string
+
+// Contains all the watcher event kinds
+const (
+	// The product was out of stock and now is in stock
+	RestockedEvent EventKind = "restocked"
+	// The product was in stock and now is out of stock
+	SoldOutEvent EventKind = "sold_out"
+	// The product price changed
+	PriceChangedEvent EventKind = "price_changed"
+	// The product details couldn't be fetched
+	FetchFailedEvent EventKind = "fetch_failed"
+	// A checkout has been created (sent by InstanceCheckout to the session notifiers, not by the watcher)
+	CheckoutCreatedEvent EventKind = "checkout_created"
+)
+
+// Represent a state transition of a watched product
+type Event struct {
+	// The kind of the event
+	Kind EventKind
+	// The watched product combination
+	Target ProductDetailsBody
+	// The product fetched by the poll that generated the event (nil for FetchFailedEvent)
+	Product *Product
+	// The last product fetched before this poll (nil if the product has never been fetched)
+	Previous *Product
+	// The error of the fetch (only for FetchFailedEvent)
+	Err error
+	// The cart items of the checkout (only for CheckoutCreatedEvent)
+	Cart []CartItem
+	// When the event has been generated
+	Time time.Time
+}
+
+// Represent the flat view of an event used by the json encoding, the notifiers payloads and templates
+// (the product fields are empty for the checkout events, that have the cart lines instead)
+type EventSummary struct {
+	Kind          EventKind         `json:"kind"`
+	Time          time.Time         `json:"time"`
+	SirID         string            `json:"sir_id,omitempty"`
+	SizeID        string            `json:"size_id,omitempty"`
+	ColorID       string            `json:"color_id,omitempty"`
+	MPad          string            `json:"mousepad,omitempty"`
+	Size          string            `json:"size,omitempty"`
+	Color         string            `json:"color,omitempty"`
+	Name          string            `json:"name"`
+	Prefix        string            `json:"prefix,omitempty"`
+	Barcode       string            `json:"barcode,omitempty"`
+	InStock       bool              `json:"in_stock"`
+	Price         Yen               `json:"price,omitempty"`
+	PreviousPrice Yen               `json:"previous_price,omitempty"`
+	Quantity      int               `json:"quantity,omitempty"`
+	Cart          []CartLineSummary `json:"cart,omitempty"`
+	Url           string            `json:"url,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// Represent the flat view of a cart item of a checkout event
+type CartLineSummary struct {
+	Id       string `json:"id"`
+	Prefix   string `json:"prefix"`
+	Name     string `json:"name"`
+	SirID    string `json:"sir_id,omitempty"`
+	SizeID   string `json:"size_id,omitempty"`
+	ColorID  string `json:"color_id,omitempty"`
+	Quantity int    `json:"quantity"`
+	Price    Yen    `json:"price"`
+	Total    Yen    `json:"total"`
+}
+
+// Return's the flat view of the event, the name falls back to the catalog names and the url to the MPadUrls one
+// if the product has never been fetched
+func (event Event) Summary() EventSummary {
+	payload := EventSummary{
+		Kind:    event.Kind,
+		Time:    event.Time,
+		SirID:   string(event.Target.SirID),
+		SizeID:  string(event.Target.SizeID),
+		ColorID: string(event.Target.ColorID),
+		MPad:    event.Target.SirID.String(),
+		Size:    event.Target.SizeID.String(),
+		Color:   event.Target.ColorID.String(),
+		Url:     MPadUrls[event.Target.SirID],
+	}
+
+	payload.Name = payload.MPad + " " + payload.Size + " " + payload.Color
+
+	// A checkout has no target, the price is the cart total
+	if event.Kind == CheckoutCreatedEvent {
+		payload.Url = ""
+		for _, item := range event.Cart {
+			payload.Quantity += int(item.Quantity)
+			payload.Price = payload.Price.Add(item.Total())
+
+			line := CartLineSummary{Quantity: int(item.Quantity), Total: item.Total()}
+			if p := item.Product; p != nil {
+				line.Id, line.Prefix, line.Name, line.Price = p.Id, p.Prefix, p.FullName, p.PriceYen
+				if p.ProductDetailsBody != nil {
+					line.SirID, line.SizeID, line.ColorID = string(p.SirID), string(p.SizeID), string(p.ColorID)
+				}
+			}
+
+			payload.Cart = append(payload.Cart, line)
+		}
+
+		payload.Name = fmt.Sprintf("%d product(s) for %s", payload.Quantity, payload.Price)
+	}
+
+	if p := event.Product; p != nil {
+		payload.Prefix, payload.InStock, payload.Price = p.Prefix, !p.OutOfStock, p.PriceYen
+		if p.FullName != "" {
+			payload.Name = p.FullName
+		}
+
+		if p.Url != "" {
+			payload.Url = p.Url
+		}
+
+		if !p.OutOfStock {
+			payload.Barcode = p.Id
+		}
+	}
+
+	if previous := event.Previous; previous != nil {
+		payload.PreviousPrice = previous.PriceYen
+		if event.Product == nil && previous.Url != "" {
+			payload.Url = previous.Url
+		}
+	}
+
+	if event.Err != nil {
+		payload.Error = event.Err.Error()
+	}
+
+	return payload
+}
+
+// Return's a short description of the event like "Restocked: HIEN FX XSOFT S Wine red"
+func (event Event) Title() string {
+	return event.Summary().Title()
+}
+
+// Return's a short description of the event like "Restocked: HIEN FX XSOFT S Wine red"
+func (summary EventSummary) Title() string {
+	var kind string
+	switch summary.Kind {
+	case RestockedEvent:
+		kind = "Restocked"
+	case SoldOutEvent:
+		kind = "Sold out"
+	case PriceChangedEvent:
+		kind = "Price changed"
+	case FetchFailedEvent:
+		kind = "Fetch failed"
+	case CheckoutCreatedEvent:
+		kind = "Checkout created"
+	default:
+		kind = string(summary.Kind)
+	}
+
+	return kind + ": " + summary.Name
+}
+
+// Return's the quantity and price of the cart line like "2 x ¥2,700 = ¥5,400"
+func (line CartLineSummary) Text() string {
+	return fmt.Sprintf("%d x %s = %s", line.Quantity, line.Price, line.Total)
+}
+
+// Return's the price like "¥2,700" or "¥2,800 (was ¥2,700)", "-" if the price is unknown
+func (summary EventSummary) PriceText() string {
+	if summary.Price == 0 {
+		return "-"
+	}
+
+	if summary.PreviousPrice != 0 && summary.PreviousPrice != summary.Price {
+		return fmt.Sprintf("%s (was %s)", summary.Price, summary.PreviousPrice)
+	}
+
+	return summary.Price.String()
+}
+
+// Encode the event as a flat json object with the product names, the price and the page url (the error is encoded as it's message)
+func (event Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(event.Summary())
+}
+
+// Contains options for the creation of a new Watcher
+type WatcherOptions struct {
+	// The product combinations to watch (see AllProductsDetails to find them)
+	Targets []ProductDetailsBody
+	// The time between the start of two polls (if zero DefaultWatchInterval is used), the targets of a poll are fetched
+	// sequentially so the session rate limiter and retry policy apply
+	Interval time.Duration
+	// Optional notifiers called with the events of every poll (if any) after they have been sent on the channel,
+	// their failures are logged with the session logger
+	Notifiers []Notifier
+}
+
+// Represent a destination of the watcher events (a webhook, an email, etc..), every notifier chooses which event kinds to deliver
+type Notifier interface {
+	Notify(ctx context.Context, events []Event) error
+}
+
+// Represent a stock watcher on top of an APISession
+type Watcher struct {
+	api     *APISession
+	options WatcherOptions
+}
+
+// Create's a new watcher of the given targets, return's an ErrInvalidArgument error if the session is nil or there are no targets
+func NewWatcher(api *APISession, options WatcherOptions) (*Watcher, error) {
+	if api == nil {
+		return nil, fmt.Errorf("%w: session cannot be nil", ErrInvalidArgument)
+	}
+
+	if len(options.Targets) == 0 {
+		return nil, fmt.Errorf("%w: the watcher has no targets", ErrInvalidArgument)
+	}
+
+	if options.Interval < 0 {
+		return nil, fmt.Errorf("%w: negative watch interval %s", ErrInvalidArgument, options.Interval)
+	}
+
+	if options.Interval == 0 {
+		options.Interval = DefaultWatchInterval
+	}
+
+	options.Targets = append([]ProductDetailsBody(nil), options.Targets...)
+	options.Notifiers = append([]Notifier(nil), options.Notifiers...)
+
+	return &Watcher{api: api, options: options}, nil
+}
+
+// Start polling the targets (the first poll starts immediately), the events are sent on the returned channel that's closed once
+// the context is done. The channel is unbuffered so the polls wait for the events to be received, every call starts an independent watch
+func (watcher *Watcher) Start(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(watcher.options.Interval)
+		defer ticker.Stop()
+
+		states := map[ProductDetailsBody]*Product{}
+		for {
+			if !watcher.poll(ctx, states, events) {
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+// Fetch every target once, send the events of the changed ones and notify them, return's false if the context is done
+func (watcher *Watcher) poll(ctx context.Context, states map[ProductDetailsBody]*Product, events chan<- Event) bool {
+	var sent []Event
+	for _, target := range watcher.options.Targets {
+		p, err := watcher.api.ProductDetailsCtx(ctx, target)
+		if ctx.Err() != nil {
+			return false
+		}
+
+		previous, seen := states[target]
+
+		var changes []Event
+		switch {
+		case err != nil:
+			changes = append(changes, Event{Kind: FetchFailedEvent, Err: err})
+		case !seen:
+			// Baseline
+		case previous.OutOfStock && !p.OutOfStock:
+			changes = append(changes, Event{Kind: RestockedEvent})
+		case !previous.OutOfStock && p.OutOfStock:
+			changes = append(changes, Event{Kind: SoldOutEvent})
+		}
+
+		// Out of stock products may have no price so only two real prices are compared
+		if err == nil && seen && previous.Price != "" && p.Price != "" && previous.PriceYen != p.PriceYen {
+			changes = append(changes, Event{Kind: PriceChangedEvent})
+		}
+
+		if err == nil {
+			states[target] = p
+		}
+
+		for _, event := range changes {
+			event.Target, event.Product, event.Previous, event.Time = target, p, previous, time.Now()
+
+			select {
+			case events <- event:
+				sent = append(sent, event)
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+
+	if len(sent) > 0 {
+		for _, notifier := range watcher.options.Notifiers {
+			if err := notifier.Notify(ctx, sent); err != nil {
+				watcher.api.logger.ErrorContext(ctx, "notification failed", slog.String("notifier", fmt.Sprintf("%T", notifier)), slog.Any("error", err))
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,304 @@
+package artisan
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// *********** WEBHOOK NOTIFIER ***********
+
+// Represent the format of the payload posted by a WebhookNotifier
+type WebhookFormat string
+
+// Contains all the built-in webhook formats
+const (
+	// {"events": [...]} with every event encoded by Event.MarshalJSON
+	JSONWebhookFormat WebhookFormat = "json"
+	// A Discord message with an embed for every event (at most 10 embeds per message, more events are split in more messages)
+	DiscordWebhookFormat WebhookFormat = "discord"
+	// A Slack message with a section block for every event (at most 50 blocks per message, more events are split in more messages)
+	SlackWebhookFormat WebhookFormat = "slack"
+)
+
+// The event kinds delivered if WebhookNotifier.Kinds is empty
+var DefaultWebhookKinds = []EventKind{RestockedEvent}
+
+// Represent a Notifier that posts the watcher events to one or more webhook urls
+type WebhookNotifier struct {
+	// The urls every payload is posted to
+	URLs []string
+	// The payload format (if empty JSONWebhookFormat is used), ignored if Payload is set
+	Format WebhookFormat
+	// An optional custom payload builder, it's called with at most BatchSize events and return's the json body to post
+	Payload func(events []Event) ([]byte, error)
+	// The max number of events per payload (if zero the format limit is used, no limit for json and custom payloads)
+	BatchSize int
+	// The event kinds delivered (if empty DefaultWebhookKinds is used)
+	Kinds []EventKind
+	// Optional headers added to every request (like an Authorization header)
+	Headers http.Header
+	// An optional http client used to post the payloads (if nil a client with a 10s timeout is used)
+	HTTPClient *http.Client
+	// An optional policy used to retry the failed deliveries (if nil deliveries are never retried)
+	RetryPolicy *RetryPolicy
+}
+
+// Create's a new webhook notifier posting the restock events to the urls in the given format, failed deliveries are
+// retried with a copy of DefaultRetryPolicy
+func NewWebhookNotifier(format WebhookFormat, urls ...string) *WebhookNotifier {
+	retryPolicy := DefaultRetryPolicy
+	return &WebhookNotifier{URLs: urls, Format: format, RetryPolicy: &retryPolicy}
+}
+
+// The http client used if WebhookNotifier.HTTPClient is nil
+var defaultWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Post the events of the notifier kinds to every url, the error joins the failed deliveries
+func (notifier *WebhookNotifier) Notify(ctx context.Context, events []Event) error {
+	kinds := notifier.Kinds
+	if len(kinds) == 0 {
+		kinds = DefaultWebhookKinds
+	}
+
+	var selected []Event
+	for _, event := range events {
+		if slices.Contains(kinds, event.Kind) {
+			selected = append(selected, event)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	// Build the payloads first so a format error doesn't deliver half of them
+	build, batchSize, err := notifier.builder()
+	if err != nil {
+		return err
+	}
+
+	var payloads [][]byte
+	for start := 0; start < len(selected); start += batchSize {
+		payload, err := build(selected[start:min(start+batchSize, len(selected))])
+		if err != nil {
+			return fmt.Errorf("Failed to build the webhook payload: %w", err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	var errs []error
+	for _, webhookUrl := range notifier.URLs {
+		for _, payload := range payloads {
+			if err := notifier.post(ctx, webhookUrl, payload); err != nil {
+				errs = append(errs, fmt.Errorf("Failed to deliver the webhook to %s: %w", webhookUrl, err))
+				break
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Return's the payload builder and the batch size of the notifier
+func (notifier *WebhookNotifier) builder() (func(events []Event) ([]byte, error), int, error) {
+	if notifier.Payload != nil {
+		return notifier.Payload, notifier.batchSize(0), nil
+	}
+
+	switch notifier.Format {
+	case JSONWebhookFormat, "":
+		return jsonWebhookPayload, notifier.batchSize(0), nil
+	case DiscordWebhookFormat:
+		return discordWebhookPayload, notifier.batchSize(10), nil
+	case SlackWebhookFormat:
+		return slackWebhookPayload, notifier.batchSize(50), nil
+	default:
+		return nil, 0, fmt.Errorf("%w: unknown webhook format %q", ErrInvalidArgument, notifier.Format)
+	}
+}
+
+// Return's the notifier batch size capped to the format limit (a zero limit means no limit)
+func (notifier *WebhookNotifier) batchSize(limit int) int {
+	size := notifier.BatchSize
+	if limit > 0 && (size <= 0 || size > limit) {
+		size = limit
+	}
+
+	if size <= 0 {
+		size = math.MaxInt
+	}
+
+	return size
+}
+
+// Post a single payload retrying the transient failures
+func (notifier *WebhookNotifier) post(ctx context.Context, webhookUrl string, payload []byte) error {
+	client := notifier.HTTPClient
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	return notifier.RetryPolicy.do(ctx, nil, func(attempt int) error {
+		req, err := http.NewRequestWithContext(ctx, "POST", webhookUrl, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+
+		for name, values := range notifier.Headers {
+			req.Header[name] = slices.Clone(values)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		return checkResponseStatus(resp)
+	})
+}
+
+// Build the JSONWebhookFormat payload
+func jsonWebhookPayload(events []Event) ([]byte, error) {
+	return json.Marshal(struct {
+		Events []Event `json:"events"`
+	}{Events: events})
+}
+
+// Contains the embed color of every event kind in the Discord payloads
+var discordEventColors = map[EventKind]int{
+	RestockedEvent:       0x2ecc71,
+	SoldOutEvent:         0xe74c3c,
+	PriceChangedEvent:    0xf1c40f,
+	FetchFailedEvent:     0x95a5a6,
+	CheckoutCreatedEvent: 0x3498db,
+}
+
+// Build the DiscordWebhookFormat payload
+func discordWebhookPayload(events []Event) ([]byte, error) {
+	type field struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	}
+
+	type embed struct {
+		Title     string    `json:"title"`
+		Url       string    `json:"url,omitempty"`
+		Color     int       `json:"color"`
+		Fields    []field   `json:"fields"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	message := struct {
+		Content string  `json:"content"`
+		Embeds  []embed `json:"embeds"`
+	}{Content: webhookSummary(events)}
+
+	for _, event := range events {
+		payload := event.Summary()
+
+		// Discord rejects the embeds with empty field values (and more than 25 fields)
+		var fields []field
+		if event.Kind == CheckoutCreatedEvent {
+			for _, line := range payload.Cart[:min(len(payload.Cart), 24)] {
+				fields = append(fields, field{Name: cmp.Or(line.Name, line.Prefix, line.Id, "-"), Value: line.Text()})
+			}
+
+			fields = append(fields, field{Name: "Total", Value: payload.PriceText()})
+		} else {
+			fields = []field{
+				{Name: "Color", Value: cmp.Or(payload.Color, "-"), Inline: true},
+				{Name: "Size", Value: cmp.Or(payload.Size, "-"), Inline: true},
+				{Name: "Price", Value: payload.PriceText(), Inline: true},
+			}
+		}
+
+		if payload.Error != "" {
+			fields = append(fields, field{Name: "Error", Value: payload.Error})
+		}
+
+		message.Embeds = append(message.Embeds, embed{
+			Title:     payload.Title(),
+			Url:       payload.Url,
+			Color:     discordEventColors[event.Kind],
+			Fields:    fields,
+			Timestamp: payload.Time,
+		})
+	}
+
+	return json.Marshal(message)
+}
+
+// Build the SlackWebhookFormat payload
+func slackWebhookPayload(events []Event) ([]byte, error) {
+	type text struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+
+	type block struct {
+		Type string `json:"type"`
+		Text text   `json:"text"`
+	}
+
+	message := struct {
+		Text   string  `json:"text"`
+		Blocks []block `json:"blocks"`
+	}{Text: webhookSummary(events)}
+
+	for _, event := range events {
+		payload := event.Summary()
+
+		title := slackEscape(payload.Title())
+		if payload.Url != "" {
+			title = "<" + payload.Url + "|" + title + ">"
+		}
+
+		var body string
+		if event.Kind == CheckoutCreatedEvent {
+			body = "*" + title + "*"
+			for _, line := range payload.Cart {
+				body += "\n" + slackEscape(cmp.Or(line.Name, line.Prefix, line.Id)+": "+line.Text())
+			}
+		} else {
+			body = fmt.Sprintf("*%s*\nColor: %s | Size: %s | Price: %s", title, slackEscape(payload.Color), slackEscape(payload.Size), payload.PriceText())
+		}
+
+		if payload.Error != "" {
+			body += "\nError: " + slackEscape(payload.Error)
+		}
+
+		message.Blocks = append(message.Blocks, block{Type: "section", Text: text{Type: "mrkdwn", Text: body}})
+	}
+
+	return json.Marshal(message)
+}
+
+// Return's the text shown in the notifications previews like "2 Artisan stock events"
+func webhookSummary(events []Event) string {
+	if len(events) == 1 {
+		return events[0].Title()
+	}
+
+	return fmt.Sprintf("%d Artisan stock events", len(events))
+}
+
+// Escape the characters with a special meaning in the Slack mrkdwn
+var slackReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackReplacer.Replace(s)
+}